@@ -2,10 +2,18 @@ package main
 
 import (
 	"Adven-Chores/internal/database"
+	"Adven-Chores/internal/digest"
+	"Adven-Chores/internal/events"
 	"Adven-Chores/internal/handlers"
+	"Adven-Chores/internal/models"
+	"Adven-Chores/internal/notify"
+	"Adven-Chores/internal/photos"
+	"Adven-Chores/internal/webhooks"
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/slate20/goauth"
@@ -30,6 +38,64 @@ func main() {
 		return
 	}
 
+	// In-process hub for live updates across devices
+	hub := events.NewHub()
+
+	// Outgoing webhooks for home-automation integrations
+	hub.AddListener(webhooks.NewDeliverer(db).HandleEvent)
+
+	// Parent notifications; email is only sent when an SMTP server is configured
+	notifier := notify.NewDispatcher(db, notify.NewWebhookNotifier())
+	if smtpAddr := os.Getenv("SMTP_ADDR"); smtpAddr != "" {
+		mailer := notify.NewSMTPMailer(smtpAddr, os.Getenv("SMTP_FROM"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+		notifier.Register(notify.NewSMTPNotifier(mailer))
+
+		// The weekly digest goes out once the week is over
+		go digest.NewSender(db, mailer).Run(time.Hour)
+	}
+
+	// Photo proof of completion is kept in the data directory until the
+	// assignment is rewarded or the retention period runs out
+	dataDir, err := database.DataDir()
+	if err != nil {
+		fmt.Println("Error locating data directory:", err)
+		return
+	}
+	retentionDays := 14
+	if days, err := strconv.Atoi(os.Getenv("PHOTO_RETENTION_DAYS")); err == nil && days >= 0 {
+		retentionDays = days
+	}
+	photoStore, err := photos.NewStore(db, filepath.Join(dataDir, "photos"), time.Duration(retentionDays)*24*time.Hour)
+	if err != nil {
+		fmt.Println("Error initializing photo store:", err)
+		return
+	}
+	go photoStore.Run(time.Hour)
+
+	// Interest on savings jars is paid by a background job
+	go func() {
+		for {
+			handlers.PayJarInterest(db, time.Now())
+			time.Sleep(time.Hour)
+		}
+	}()
+
+	// Competitions are closed and their prizes granted by a background job
+	go func() {
+		for {
+			handlers.CloseCompetitions(db, hub, notifier, time.Now())
+			time.Sleep(time.Hour)
+		}
+	}()
+
+	// Bounties are taken off the board when they expire
+	go func() {
+		for {
+			handlers.ExpireBounties(db, hub, notifier, time.Now())
+			time.Sleep(time.Minute)
+		}
+	}()
+
 	// serve static files
 	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("../../static"))))
 
@@ -38,9 +104,11 @@ func main() {
 	http.HandleFunc("/register", handlers.RegisterHandler(db, auth))
 	http.HandleFunc("/login", handlers.LoginHandler(db, auth))
 	http.HandleFunc("/logout", handlers.LogoutHandler())
+	http.HandleFunc("/ics/{token}", handlers.ICSFeedHandler(db))
 
 	// Protected routes
 	http.HandleFunc("/home", authMiddleware(handlers.HomeHandler(db, auth)))
+	http.HandleFunc("/events", authMiddleware(handlers.EventsHandler(hub, auth)))
 	http.HandleFunc("/child-nav", authMiddleware(handlers.ChildNavHandler(db, auth)))
 	http.HandleFunc("/child-list", authMiddleware(handlers.ChildListHandler(db, auth)))
 	http.HandleFunc("/chore-list", authMiddleware(handlers.ChoreListHandler(db, auth)))
@@ -49,27 +117,113 @@ func main() {
 	http.HandleFunc("/add-child", authMiddleware(handlers.AddChildHandler(db, auth)))
 	http.HandleFunc("/edit-child/{id}", authMiddleware(handlers.EditChildHandler(db, auth)))
 	http.HandleFunc("/delete-child/{id}", authMiddleware(handlers.DeleteChildHandler(db, auth)))
+	http.HandleFunc("/adjust-points/{child_id}", authMiddleware(handlers.AdjustPointsHandler(db, auth, hub, notifier)))
+	http.HandleFunc("/adjustment-list", authMiddleware(handlers.AdjustmentListHandler(db, auth)))
 	http.HandleFunc("/child-action", authMiddleware(handlers.ChildActionHandler(db)))
-	http.HandleFunc("/add-chore", authMiddleware(handlers.AddChoreHandler(db, auth)))
+	http.HandleFunc("/add-chore", authMiddleware(handlers.AddChoreHandler(db, auth, hub)))
 	http.HandleFunc("/edit-chore/{id}", authMiddleware(handlers.EditChoreHandler(db, auth)))
 	http.HandleFunc("/delete-chore/{id}", authMiddleware(handlers.DeleteChoreHandler(db, auth)))
+	http.HandleFunc("/suggest-points", authMiddleware(handlers.SuggestPointsHandler(db, auth)))
+	http.HandleFunc("/rebalance-chores", authMiddleware(handlers.RebalanceChoresHandler(db, auth)))
+	http.HandleFunc("/chore-pricing", authMiddleware(handlers.ChorePricingHandler(db, auth)))
+	http.HandleFunc("/apply-suggested-points/{id}", authMiddleware(handlers.ApplySuggestedPointsHandler(db, auth)))
 	http.HandleFunc("/chore-action", authMiddleware(handlers.ChoreActionHandler(db)))
+	http.HandleFunc("/chore-steps/{id}", authMiddleware(handlers.ChoreStepsHandler(db, auth)))
+	http.HandleFunc("/add-chore-step/{id}", authMiddleware(handlers.AddChoreStepHandler(db, auth)))
+	http.HandleFunc("/delete-chore-step/{id}", authMiddleware(handlers.DeleteChoreStepHandler(db, auth)))
+	http.HandleFunc("/move-chore-step/{id}", authMiddleware(handlers.MoveChoreStepHandler(db, auth)))
+	http.HandleFunc("/toggle-step/{assignment_id}/{step_id}", authMiddleware(handlers.ToggleAssignmentStepHandler(db, auth)))
+	http.HandleFunc("/bulk-chores", authMiddleware(handlers.BulkChoresHandler(db, auth, hub)))
+	http.HandleFunc("/save-chore-template/{id}", authMiddleware(handlers.SaveChoreTemplateHandler(db, auth)))
+	http.HandleFunc("/delete-chore-template/{id}", authMiddleware(handlers.DeleteChoreTemplateHandler(db, auth)))
 	http.HandleFunc("/assign-chore", authMiddleware(handlers.AssignChoreHandler(db, auth)))
 	http.HandleFunc("/assign-chore-form", authMiddleware(handlers.AssignChoreFormHandler(db, auth)))
 	http.HandleFunc("/assignments-list", authMiddleware(handlers.AssignmentsListHandler(db, auth)))
-	http.HandleFunc("/delete-assignment/{id}", authMiddleware(handlers.DeleteAssignmentHandler(db, auth)))
+	http.HandleFunc("/delete-assignment/{id}", authMiddleware(handlers.DeleteAssignmentHandler(db, auth, photoStore)))
 	http.HandleFunc("/assignment-action", authMiddleware(handlers.AssignmentActionHandler(db)))
 	http.HandleFunc("/accept-chore/{child_id}/{chore_id}", authMiddleware(handlers.AcceptChoreHandler(db, auth)))
-	http.HandleFunc("/complete-chore/{id}", authMiddleware(handlers.CompleteAssignmentHandler(db, auth)))
-	http.HandleFunc("/reward-assignment/{id}", authMiddleware(handlers.RewardAssignmentHandler(db, auth)))
+	http.HandleFunc("/complete-chore/{id}", authMiddleware(handlers.CompleteAssignmentHandler(db, auth, hub, notifier, photoStore)))
+	http.HandleFunc("/reward-assignment/{id}", authMiddleware(handlers.RewardAssignmentHandler(db, auth, hub, notifier, photoStore)))
+	http.HandleFunc("/assignment-photo/{id}", authMiddleware(handlers.AssignmentPhotoHandler(db, auth, photoStore)))
 	http.HandleFunc("/reward-list", authMiddleware(handlers.RewardListHandler(db, auth)))
 	http.HandleFunc("/add-reward", authMiddleware(handlers.AddRewardHandler(db, auth)))
 	http.HandleFunc("/edit-reward/{id}", authMiddleware(handlers.EditRewardHandler(db, auth)))
 	http.HandleFunc("/delete-reward/{id}", authMiddleware(handlers.DeleteRewardHandler(db, auth)))
+	http.HandleFunc("/store-rules", authMiddleware(handlers.StoreRulesHandler(db, auth)))
 	http.HandleFunc("/reward-action", authMiddleware(handlers.RewardActionHandler(db)))
 	http.HandleFunc("/rewards-store/{child_id}", authMiddleware(handlers.RewardsStoreHandler(db, auth)))
-	http.HandleFunc("/redeem-reward/", authMiddleware(handlers.RedeemRewardHandler(db, auth)))
+	http.HandleFunc("/redeem-reward/", authMiddleware(handlers.RedeemRewardHandler(db, auth, hub, notifier)))
+	http.HandleFunc("/set-savings-goal/{child_id}", authMiddleware(handlers.SetSavingsGoalHandler(db, auth, notifier)))
+	http.HandleFunc("/lock-goal-points/{child_id}", authMiddleware(handlers.LockGoalPointsHandler(db, auth)))
+	http.HandleFunc("/clear-savings-goal/{child_id}", authMiddleware(handlers.ClearSavingsGoalHandler(db, auth)))
+	http.HandleFunc("/allowance-list", authMiddleware(handlers.AllowanceListHandler(db, auth)))
+	http.HandleFunc("/allowance-settings", authMiddleware(handlers.AllowanceSettingsHandler(db, auth)))
+	http.HandleFunc("/allowance-action", authMiddleware(handlers.AllowanceActionHandler(db)))
+	http.HandleFunc("/cash-out/{child_id}", authMiddleware(handlers.CashOutHandler(db, auth, hub, notifier)))
+	http.HandleFunc("/approve-cash-out/{id}", authMiddleware(handlers.ApproveCashOutHandler(db, auth)))
+	http.HandleFunc("/pay-cash-out/{id}", authMiddleware(handlers.PayCashOutHandler(db, auth)))
+	http.HandleFunc("/reject-cash-out/{id}", authMiddleware(handlers.RejectCashOutHandler(db, auth)))
+	http.HandleFunc("/jar-settings", authMiddleware(handlers.JarSettingsHandler(db, auth)))
+	http.HandleFunc("/jar-action", authMiddleware(handlers.JarActionHandler(db, auth)))
+	http.HandleFunc("/transfer-jars/{child_id}", authMiddleware(handlers.TransferJarsHandler(db, auth)))
+	http.HandleFunc("/give-from-jar/{child_id}", authMiddleware(handlers.GiveFromJarHandler(db, auth)))
+	http.HandleFunc("/leaderboard", authMiddleware(handlers.LeaderboardHandler(db, auth)))
+	http.HandleFunc("/competition-list", authMiddleware(handlers.CompetitionListHandler(db, auth)))
+	http.HandleFunc("/add-competition", authMiddleware(handlers.AddCompetitionHandler(db, auth)))
+	http.HandleFunc("/delete-competition/{id}", authMiddleware(handlers.DeleteCompetitionHandler(db, auth)))
+	http.HandleFunc("/competition-action", authMiddleware(handlers.CompetitionActionHandler(db)))
+	http.HandleFunc("/quest-list", authMiddleware(handlers.QuestListHandler(db, auth)))
+	http.HandleFunc("/add-quest", authMiddleware(handlers.AddQuestHandler(db, auth)))
+	http.HandleFunc("/edit-quest/{id}", authMiddleware(handlers.EditQuestHandler(db, auth)))
+	http.HandleFunc("/delete-quest/{id}", authMiddleware(handlers.DeleteQuestHandler(db, auth)))
+	http.HandleFunc("/start-quest/{id}", authMiddleware(handlers.StartQuestHandler(db, auth)))
+	http.HandleFunc("/abandon-quest/{id}", authMiddleware(handlers.AbandonQuestHandler(db, auth)))
+	http.HandleFunc("/quest-action", authMiddleware(handlers.QuestActionHandler(db)))
+	http.HandleFunc("/child-quests/{child_id}", authMiddleware(handlers.ChildQuestsHandler(db, auth)))
+	http.HandleFunc("/avatar-shop/{child_id}", authMiddleware(handlers.AvatarShopHandler(db, auth)))
+	http.HandleFunc("/choose-job/{child_id}", authMiddleware(handlers.ChooseJobHandler(db, auth)))
+	http.HandleFunc("/buy-cosmetic/{child_id}", authMiddleware(handlers.BuyCosmeticHandler(db, auth)))
+	http.HandleFunc("/equip-cosmetic/{child_id}", authMiddleware(handlers.EquipCosmeticHandler(db, auth)))
+	http.HandleFunc("/unequip-cosmetic/{child_id}", authMiddleware(handlers.UnequipCosmeticHandler(db, auth)))
+	http.HandleFunc("/bounty-list", authMiddleware(handlers.BountyListHandler(db, auth)))
+	http.HandleFunc("/add-bounty", authMiddleware(handlers.AddBountyHandler(db, auth, hub)))
+	http.HandleFunc("/delete-bounty/{id}", authMiddleware(handlers.DeleteBountyHandler(db, auth)))
+	http.HandleFunc("/bounty-action", authMiddleware(handlers.BountyActionHandler(db)))
+	http.HandleFunc("/child-bounties/{child_id}", authMiddleware(handlers.ChildBountiesHandler(db, auth)))
+	http.HandleFunc("/claim-bounty/{id}", authMiddleware(handlers.ClaimBountyHandler(db, auth, hub, notifier)))
+	http.HandleFunc("/propose-swap/{child_id}", authMiddleware(handlers.ProposeSwapHandler(db, auth, hub)))
+	http.HandleFunc("/swap-action/{child_id}", authMiddleware(handlers.SwapActionHandler(db, auth)))
+	http.HandleFunc("/child-swaps/{child_id}", authMiddleware(handlers.ChildSwapsHandler(db, auth)))
+	http.HandleFunc("/accept-swap/{id}", authMiddleware(handlers.AcceptSwapHandler(db, auth, hub, notifier)))
+	http.HandleFunc("/decline-swap/{id}", authMiddleware(handlers.CloseSwapHandler(db, auth, models.SwapDeclined)))
+	http.HandleFunc("/cancel-swap/{id}", authMiddleware(handlers.CloseSwapHandler(db, auth, models.SwapCancelled)))
+	http.HandleFunc("/approve-swap/{id}", authMiddleware(handlers.ApproveSwapHandler(db, auth, hub)))
+	http.HandleFunc("/reject-swap/{id}", authMiddleware(handlers.CloseSwapHandler(db, auth, models.SwapRejected)))
+	http.HandleFunc("/swap-list", authMiddleware(handlers.SwapListHandler(db, auth)))
+	http.HandleFunc("/swap-rules", authMiddleware(handlers.SwapRulesHandler(db, auth)))
+	http.HandleFunc("/swap-rules-action", authMiddleware(handlers.SwapRulesActionHandler(db)))
+	http.HandleFunc("/calendar", authMiddleware(handlers.CalendarHandler(db, auth)))
+	http.HandleFunc("/child-calendar/{child_id}", authMiddleware(handlers.ChildCalendarHandler(db, auth)))
+	http.HandleFunc("/calendar-feeds", authMiddleware(handlers.CalendarFeedsHandler(db, auth)))
+	http.HandleFunc("/reset-calendar-feed/{child_id}", authMiddleware(handlers.ResetCalendarFeedHandler(db, auth)))
+	http.HandleFunc("/calendar-action", authMiddleware(handlers.CalendarActionHandler(db)))
+	http.HandleFunc("/child-history/{child_id}", authMiddleware(handlers.ChildHistoryHandler(db, auth)))
+	http.HandleFunc("/chore-history/{chore_id}", authMiddleware(handlers.ChoreHistoryHandler(db, auth)))
+	http.HandleFunc("/stats", authMiddleware(handlers.StatsHandler(db, auth)))
+	http.HandleFunc("/digest", authMiddleware(handlers.DigestHandler(db, auth)))
+	http.HandleFunc("/chore-chart/{child_id}", authMiddleware(handlers.ChoreChartHandler(db, auth)))
+	http.HandleFunc("/coupons/{child_id}", authMiddleware(handlers.CouponsHandler(db, auth)))
 	http.HandleFunc("/set-pin", authMiddleware(handlers.SetPinHandler(db, auth)))
+	http.HandleFunc("/notification-list", authMiddleware(handlers.NotificationListHandler(db, auth)))
+	http.HandleFunc("/read-notification/{id}", authMiddleware(handlers.ReadNotificationHandler(db, auth)))
+	http.HandleFunc("/read-all-notifications", authMiddleware(handlers.ReadAllNotificationsHandler(db, auth)))
+	http.HandleFunc("/notification-preferences", authMiddleware(handlers.NotificationPreferencesHandler(db, auth)))
+	http.HandleFunc("/notification-action", authMiddleware(handlers.NotificationActionHandler(db)))
+	http.HandleFunc("/webhook-list", authMiddleware(handlers.WebhookListHandler(db, auth)))
+	http.HandleFunc("/add-webhook", authMiddleware(handlers.AddWebhookHandler(db, auth)))
+	http.HandleFunc("/delete-webhook/{id}", authMiddleware(handlers.DeleteWebhookHandler(db, auth)))
+	http.HandleFunc("/webhook-action", authMiddleware(handlers.WebhookActionHandler(db)))
+	http.HandleFunc("/webhook-deliveries", authMiddleware(handlers.WebhookDeliveriesHandler(db, auth)))
 
 	// Start the server
 	fmt.Println("Starting server on port 8080")
@@ -0,0 +1,152 @@
+// Package calendar lays assignments out on a weekly calendar and exports
+// them as an iCalendar (RFC 5545) feed.
+package calendar
+
+import (
+	"Adven-Chores/internal/models"
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Day is one day of a weekly calendar
+type Day struct {
+	Date        time.Time
+	Today       bool
+	Assignments []*models.Assignment
+}
+
+// Week is seven days starting on a Monday
+type Week struct {
+	Start time.Time
+	Days  []*Day
+	// Anytime holds the assignments without a due date
+	Anytime []*models.Assignment
+}
+
+// function to get the Monday that starts the week containing t
+func WeekStart(t time.Time) time.Time {
+	t = t.In(time.Local)
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.Local)
+}
+
+// function to lay assignments out on the week containing start
+func BuildWeek(assignments []*models.Assignment, start, now time.Time) *Week {
+	week := &Week{Start: WeekStart(start)}
+	y, m, d := now.In(time.Local).Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+
+	for i := 0; i < 7; i++ {
+		day := &Day{Date: week.Start.AddDate(0, 0, i)}
+		day.Today = day.Date.Equal(today)
+		for _, a := range assignments {
+			if a.OccursOn(day.Date) {
+				day.Assignments = append(day.Assignments, a)
+			}
+		}
+		week.Days = append(week.Days, day)
+	}
+
+	for _, a := range assignments {
+		if a.DueDate == "" {
+			week.Anytime = append(week.Anytime, a)
+		}
+	}
+
+	return week
+}
+
+// function to get the Monday of the previous week
+func (w *Week) Previous() time.Time {
+	return w.Start.AddDate(0, 0, -7)
+}
+
+// function to get the Monday of the next week
+func (w *Week) Next() time.Time {
+	return w.Start.AddDate(0, 0, 7)
+}
+
+// function to write assignments with a due date as an iCalendar feed.
+// Repeating assignments are exported once with an RRULE.
+func WriteICS(out io.Writer, name string, assignments []*models.Assignment, now time.Time) error {
+	w := bufio.NewWriter(out)
+	stamp := now.UTC().Format("20060102T150405Z")
+
+	writeLine(w, "BEGIN:VCALENDAR")
+	writeLine(w, "VERSION:2.0")
+	writeLine(w, "PRODID:-//Adven-Chores//Chores//EN")
+	writeLine(w, "CALSCALE:GREGORIAN")
+	writeLine(w, "METHOD:PUBLISH")
+	writeLine(w, "X-WR-CALNAME:"+escapeText(name))
+
+	for _, a := range assignments {
+		due, ok := a.Due()
+		if !ok {
+			continue
+		}
+
+		writeLine(w, "BEGIN:VEVENT")
+		writeLine(w, fmt.Sprintf("UID:assignment-%d@adven-chores", a.ID))
+		writeLine(w, "DTSTAMP:"+stamp)
+		writeLine(w, "DTSTART;VALUE=DATE:"+due.Format("20060102"))
+		writeLine(w, "DTEND;VALUE=DATE:"+due.AddDate(0, 0, 1).Format("20060102"))
+		switch a.Recurrence {
+		case models.RecurrenceDaily:
+			writeLine(w, "RRULE:FREQ=DAILY")
+		case models.RecurrenceWeekly:
+			writeLine(w, "RRULE:FREQ=WEEKLY")
+		}
+		writeLine(w, "SUMMARY:"+escapeText(summary(a)))
+		if a.Chore.Category != "" {
+			writeLine(w, "CATEGORIES:"+escapeText(a.Chore.Category))
+		}
+		writeLine(w, "TRANSP:TRANSPARENT")
+		writeLine(w, "END:VEVENT")
+	}
+
+	writeLine(w, "END:VCALENDAR")
+	return w.Flush()
+}
+
+// function to describe an assignment in a calendar app
+func summary(a *models.Assignment) string {
+	s := fmt.Sprintf("%s: %s (%d points)", a.ChildName, a.Chore.Description, a.Chore.Points)
+	if a.Chore.Icon != "" {
+		s = a.Chore.Icon + " " + s
+	}
+	if a.Chore.IsRequired {
+		s += " - required"
+	}
+	if a.IsCompleted {
+		s += " ✓"
+	}
+	return s
+}
+
+// function to escape a TEXT value
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// function to write a content line, folding it at 75 octets without
+// splitting a UTF-8 character. Continuation lines start with a space.
+func writeLine(w *bufio.Writer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	w.WriteString(line + "\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
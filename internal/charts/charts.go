@@ -0,0 +1,140 @@
+// Package charts draws simple bar charts as inline SVG, so pages can show
+// charts without JavaScript or an external charting library. Colours come
+// from the series-N classes in the stylesheet.
+package charts
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"math"
+	"strings"
+)
+
+// Series is one set of values in a column chart, one value per label
+type Series struct {
+	Name   string
+	Values []int
+}
+
+// Bar is one row of a bar chart. Text is shown after the bar; the value is
+// used when it is empty.
+type Bar struct {
+	Label string
+	Value int
+	Text  string
+}
+
+// sizes of the column chart, in SVG units
+const (
+	columnWidth  = 480
+	columnHeight = 200
+	axisWidth    = 36
+	labelHeight  = 24
+	legendHeight = 20
+)
+
+// sizes of the bar chart, in SVG units
+const (
+	barWidth      = 480
+	barLabelWidth = 160
+	barTextWidth  = 60
+	rowHeight     = 24
+)
+
+// function to draw grouped columns, one group per label with a column for
+// each series
+func Columns(labels []string, series []Series) template.HTML {
+	top := 0
+	for _, s := range series {
+		for _, v := range s.Values {
+			top = max(top, v)
+		}
+	}
+	top = roundUp(top)
+
+	plotWidth := float64(columnWidth - axisWidth)
+	plotHeight := float64(columnHeight - labelHeight - legendHeight)
+	groupWidth := plotWidth / float64(max(len(labels), 1))
+	width := groupWidth * 0.8 / float64(max(len(series), 1))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %d %d" role="img">`, columnWidth, columnHeight)
+
+	// Axis with the top value and zero
+	fmt.Fprintf(&b, `<line class="axis" x1="%d" y1="%d" x2="%d" y2="%.1f"/>`, axisWidth, legendHeight, axisWidth, float64(legendHeight)+plotHeight)
+	fmt.Fprintf(&b, `<line class="axis" x1="%d" y1="%.1f" x2="%d" y2="%.1f"/>`, axisWidth, float64(legendHeight)+plotHeight, columnWidth, float64(legendHeight)+plotHeight)
+	fmt.Fprintf(&b, `<text class="tick" x="%d" y="%d" text-anchor="end">%d</text>`, axisWidth-4, legendHeight+4, top)
+	fmt.Fprintf(&b, `<text class="tick" x="%d" y="%.1f" text-anchor="end">0</text>`, axisWidth-4, float64(legendHeight)+plotHeight)
+
+	for i, s := range series {
+		x := axisWidth + i*110
+		fmt.Fprintf(&b, `<rect class="series-%d" x="%d" y="4" width="10" height="10"/>`, i, x)
+		fmt.Fprintf(&b, `<text class="legend" x="%d" y="13">%s</text>`, x+14, html.EscapeString(s.Name))
+	}
+
+	for g, label := range labels {
+		left := float64(axisWidth) + groupWidth*float64(g) + groupWidth*0.1
+		for i, s := range series {
+			value := 0
+			if g < len(s.Values) {
+				value = s.Values[g]
+			}
+			height := plotHeight * float64(value) / float64(top)
+			fmt.Fprintf(&b, `<rect class="series-%d" x="%.1f" y="%.1f" width="%.1f" height="%.1f"><title>%s %s: %d</title></rect>`,
+				i, left+width*float64(i), float64(legendHeight)+plotHeight-height, width, height,
+				html.EscapeString(label), html.EscapeString(s.Name), value)
+		}
+		fmt.Fprintf(&b, `<text class="tick" x="%.1f" y="%d" text-anchor="middle">%s</text>`,
+			float64(axisWidth)+groupWidth*(float64(g)+0.5), columnHeight-6, html.EscapeString(label))
+	}
+
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// function to draw horizontal bars scaled so top fills the chart; a top of
+// zero scales to the largest value
+func Bars(bars []Bar, top int) template.HTML {
+	if top == 0 {
+		for _, bar := range bars {
+			top = max(top, bar.Value)
+		}
+	}
+	top = max(top, 1)
+
+	plotWidth := float64(barWidth - barLabelWidth - barTextWidth)
+	height := max(len(bars), 1) * rowHeight
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %d %d" role="img">`, barWidth, height)
+	for i, bar := range bars {
+		y := i * rowHeight
+		text := bar.Text
+		if text == "" {
+			text = fmt.Sprint(bar.Value)
+		}
+		width := plotWidth * float64(max(bar.Value, 0)) / float64(top)
+
+		fmt.Fprintf(&b, `<text class="label" x="%d" y="%d" text-anchor="end">%s</text>`, barLabelWidth-6, y+16, html.EscapeString(bar.Label))
+		fmt.Fprintf(&b, `<rect class="series-0" x="%d" y="%d" width="%.1f" height="%d"><title>%s: %s</title></rect>`,
+			barLabelWidth, y+4, width, rowHeight-8, html.EscapeString(bar.Label), html.EscapeString(text))
+		fmt.Fprintf(&b, `<text class="value" x="%.1f" y="%d">%s</text>`, float64(barLabelWidth)+width+4, y+16, html.EscapeString(text))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// function to round the top of an axis up to a tidy number
+func roundUp(n int) int {
+	if n <= 0 {
+		return 10
+	}
+	step := math.Pow(10, math.Floor(math.Log10(float64(n))))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if float64(n) <= m*step {
+			return int(m * step)
+		}
+	}
+	return n
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
@@ -11,16 +12,26 @@ import (
 
 var DB *sql.DB
 
-func InitDB() (*sql.DB, error) {
-	// Get the user's home directory
+// function to get the Adven-Chores data directory in the user's home
+// directory, creating it if needed
+func DataDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		log.Fatal(err)
+		return "", err
+	}
+
+	dir := filepath.Join(homeDir, "Adven-Chores")
+	err = os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return "", err
 	}
 
+	return dir, nil
+}
+
+func InitDB() (*sql.DB, error) {
 	// Create an Adven-Chores directory in the user's home directory
-	dbDir := filepath.Join(homeDir, "Adven-Chores")
-	err = os.MkdirAll(dbDir, os.ModePerm)
+	dbDir, err := DataDir()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -155,5 +166,599 @@ func initTables() {
 		log.Fatal(err)
 	}
 
+	createNotificationsTable := `
+	CREATE TABLE IF NOT EXISTS notifications (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		child_id INTEGER,
+		kind TEXT NOT NULL,
+		title TEXT NOT NULL,
+		body TEXT NOT NULL,
+		is_read BOOLEAN NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);`
+
+	_, err = DB.Exec(createNotificationsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createNotificationPreferencesTable := `
+	CREATE TABLE IF NOT EXISTS notification_preferences (
+		user_id INTEGER PRIMARY KEY,
+		notify_completed BOOLEAN NOT NULL DEFAULT 1,
+		notify_redeemed BOOLEAN NOT NULL DEFAULT 1,
+		inbox_enabled BOOLEAN NOT NULL DEFAULT 1,
+		email_enabled BOOLEAN NOT NULL DEFAULT 0,
+		email TEXT NOT NULL DEFAULT '',
+		webhook_enabled BOOLEAN NOT NULL DEFAULT 0,
+		webhook_url TEXT NOT NULL DEFAULT '',
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);`
+
+	_, err = DB.Exec(createNotificationPreferencesTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createWebhooksTable := `
+	CREATE TABLE IF NOT EXISTS webhooks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		event_types TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);`
+
+	_, err = DB.Exec(createWebhooksTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createWebhookDeliveriesTable := `
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		webhook_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		event_type TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		attempt INTEGER NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		success BOOLEAN NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (webhook_id) REFERENCES webhooks(id)
+	);`
+
+	_, err = DB.Exec(createWebhookDeliveriesTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createChoreTemplatesTable := `
+	CREATE TABLE IF NOT EXISTS chore_templates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		description TEXT NOT NULL,
+		points INTEGER NOT NULL,
+		is_required BOOLEAN NOT NULL,
+		min_age INTEGER NOT NULL DEFAULT 0,
+		max_age INTEGER NOT NULL DEFAULT 0,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);`
+
+	_, err = DB.Exec(createChoreTemplatesTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createChoreStepsTable := `
+	CREATE TABLE IF NOT EXISTS chore_steps (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		chore_id INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		description TEXT NOT NULL,
+		is_required BOOLEAN NOT NULL DEFAULT 1,
+		FOREIGN KEY (chore_id) REFERENCES chores(id)
+	);`
+
+	_, err = DB.Exec(createChoreStepsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createAssignmentStepsTable := `
+	CREATE TABLE IF NOT EXISTS assignment_steps (
+		assignment_id INTEGER NOT NULL,
+		step_id INTEGER NOT NULL,
+		checked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (assignment_id, step_id),
+		FOREIGN KEY (assignment_id) REFERENCES assignments(id),
+		FOREIGN KEY (step_id) REFERENCES chore_steps(id)
+	);`
+
+	_, err = DB.Exec(createAssignmentStepsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createAssignmentPhotosTable := `
+	CREATE TABLE IF NOT EXISTS assignment_photos (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		assignment_id INTEGER NOT NULL UNIQUE,
+		user_id INTEGER NOT NULL,
+		filename TEXT NOT NULL,
+		thumb_filename TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (assignment_id) REFERENCES assignments(id)
+	);`
+
+	_, err = DB.Exec(createAssignmentPhotosTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Rewards with target rows are only shown to those children
+	createRewardTargetsTable := `
+	CREATE TABLE IF NOT EXISTS reward_targets (
+		reward_id INTEGER NOT NULL,
+		child_id INTEGER NOT NULL,
+		PRIMARY KEY (reward_id, child_id),
+		FOREIGN KEY (reward_id) REFERENCES rewards(id),
+		FOREIGN KEY (child_id) REFERENCES children(id)
+	);`
+
+	_, err = DB.Exec(createRewardTargetsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createRewardPricesTable := `
+	CREATE TABLE IF NOT EXISTS reward_prices (
+		reward_id INTEGER NOT NULL,
+		child_id INTEGER NOT NULL,
+		point_cost INTEGER NOT NULL,
+		PRIMARY KEY (reward_id, child_id),
+		FOREIGN KEY (reward_id) REFERENCES rewards(id),
+		FOREIGN KEY (child_id) REFERENCES children(id)
+	);`
+
+	_, err = DB.Exec(createRewardPricesTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createRedemptionsTable := `
+	CREATE TABLE IF NOT EXISTS reward_redemptions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		child_id INTEGER NOT NULL,
+		reward_id INTEGER NOT NULL,
+		description TEXT NOT NULL,
+		points INTEGER NOT NULL,
+		redeemed_at TIMESTAMP NOT NULL,
+		FOREIGN KEY (child_id) REFERENCES children(id)
+	);`
+
+	_, err = DB.Exec(createRedemptionsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createSavingsGoalsTable := `
+	CREATE TABLE IF NOT EXISTS savings_goals (
+		child_id INTEGER PRIMARY KEY,
+		reward_id INTEGER NOT NULL,
+		locked_points INTEGER NOT NULL DEFAULT 0,
+		notified BOOLEAN NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (child_id) REFERENCES children(id),
+		FOREIGN KEY (reward_id) REFERENCES rewards(id)
+	);`
+
+	_, err = DB.Exec(createSavingsGoalsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Every change to a child's points, newest last
+	createPointTransactionsTable := `
+	CREATE TABLE IF NOT EXISTS point_transactions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		child_id INTEGER NOT NULL,
+		amount INTEGER NOT NULL,
+		balance INTEGER NOT NULL,
+		kind TEXT NOT NULL,
+		description TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (child_id) REFERENCES children(id)
+	);`
+
+	_, err = DB.Exec(createPointTransactionsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createAllowanceSettingsTable := `
+	CREATE TABLE IF NOT EXISTS allowance_settings (
+		user_id INTEGER PRIMARY KEY,
+		enabled BOOLEAN NOT NULL DEFAULT 0,
+		points_per_unit INTEGER NOT NULL DEFAULT 10,
+		currency TEXT NOT NULL DEFAULT '$',
+		min_cash_out INTEGER NOT NULL DEFAULT 0,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);`
+
+	_, err = DB.Exec(createAllowanceSettingsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createCashOutsTable := `
+	CREATE TABLE IF NOT EXISTS cash_outs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		child_id INTEGER NOT NULL,
+		points INTEGER NOT NULL,
+		amount_cents INTEGER NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		method TEXT NOT NULL DEFAULT '',
+		note TEXT NOT NULL DEFAULT '',
+		requested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		paid_at TIMESTAMP,
+		FOREIGN KEY (child_id) REFERENCES children(id)
+	);`
+
+	_, err = DB.Exec(createCashOutsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createCalendarFeedsTable := `
+	CREATE TABLE IF NOT EXISTS calendar_feeds (
+		token TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		child_id INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, child_id),
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);`
+
+	_, err = DB.Exec(createCalendarFeedsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createCompetitionsTable := `
+	CREATE TABLE IF NOT EXISTS competitions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		metric TEXT NOT NULL,
+		start_date TEXT NOT NULL,
+		end_date TEXT NOT NULL,
+		prize_reward_id INTEGER NOT NULL DEFAULT 0,
+		is_closed BOOLEAN NOT NULL DEFAULT 0,
+		winners TEXT NOT NULL DEFAULT '',
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);`
+
+	_, err = DB.Exec(createCompetitionsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createQuestsTable := `
+	CREATE TABLE IF NOT EXISTS quests (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		story TEXT NOT NULL DEFAULT '',
+		deadline TEXT NOT NULL DEFAULT '',
+		bonus_points INTEGER NOT NULL DEFAULT 0,
+		bonus_reward_id INTEGER NOT NULL DEFAULT 0,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);`
+
+	_, err = DB.Exec(createQuestsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createQuestChoresTable := `
+	CREATE TABLE IF NOT EXISTS quest_chores (
+		quest_id INTEGER NOT NULL,
+		chore_id INTEGER NOT NULL,
+		PRIMARY KEY (quest_id, chore_id),
+		FOREIGN KEY (quest_id) REFERENCES quests(id),
+		FOREIGN KEY (chore_id) REFERENCES chores(id)
+	);`
+
+	_, err = DB.Exec(createQuestChoresTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createQuestRunsTable := `
+	CREATE TABLE IF NOT EXISTS quest_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		quest_id INTEGER NOT NULL,
+		child_id INTEGER NOT NULL,
+		started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		completed_at TIMESTAMP,
+		FOREIGN KEY (quest_id) REFERENCES quests(id),
+		FOREIGN KEY (child_id) REFERENCES children(id)
+	);`
+
+	_, err = DB.Exec(createQuestRunsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createQuestRunChoresTable := `
+	CREATE TABLE IF NOT EXISTS quest_run_chores (
+		run_id INTEGER NOT NULL,
+		chore_id INTEGER NOT NULL,
+		approved_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (run_id, chore_id),
+		FOREIGN KEY (run_id) REFERENCES quest_runs(id)
+	);`
+
+	_, err = DB.Exec(createQuestRunChoresTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createChorePricingTable := `
+	CREATE TABLE IF NOT EXISTS chore_pricing (
+		user_id INTEGER PRIMARY KEY,
+		points_per_minute REAL NOT NULL DEFAULT 1,
+		medium_multiplier REAL NOT NULL DEFAULT 1.5,
+		hard_multiplier REAL NOT NULL DEFAULT 2,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);`
+
+	_, err = DB.Exec(createChorePricingTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createStoreRulesTable := `
+	CREATE TABLE IF NOT EXISTS store_rules (
+		user_id INTEGER PRIMARY KEY,
+		required_chores_lock TEXT NOT NULL DEFAULT 'off',
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);`
+
+	_, err = DB.Exec(createStoreRulesTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createJarSettingsTable := `
+	CREATE TABLE IF NOT EXISTS jar_settings (
+		user_id INTEGER PRIMARY KEY,
+		enabled BOOLEAN NOT NULL DEFAULT 0,
+		spend_percent INTEGER NOT NULL DEFAULT 70,
+		save_percent INTEGER NOT NULL DEFAULT 20,
+		give_percent INTEGER NOT NULL DEFAULT 10,
+		interest_rate INTEGER NOT NULL DEFAULT 0,
+		interest_period TEXT NOT NULL DEFAULT 'month',
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);`
+
+	_, err = DB.Exec(createJarSettingsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createChildJarsTable := `
+	CREATE TABLE IF NOT EXISTS child_jars (
+		child_id INTEGER PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		spend INTEGER NOT NULL DEFAULT 0,
+		save INTEGER NOT NULL DEFAULT 0,
+		give INTEGER NOT NULL DEFAULT 0,
+		interest_paid_at TIMESTAMP,
+		FOREIGN KEY (child_id) REFERENCES children(id)
+	);`
+
+	_, err = DB.Exec(createChildJarsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createJarTransfersTable := `
+	CREATE TABLE IF NOT EXISTS jar_transfers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		child_id INTEGER NOT NULL,
+		from_jar TEXT NOT NULL,
+		to_jar TEXT NOT NULL,
+		amount INTEGER NOT NULL,
+		note TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (child_id) REFERENCES children(id)
+	);`
+
+	_, err = DB.Exec(createJarTransfersTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createChildCosmeticsTable := `
+	CREATE TABLE IF NOT EXISTS child_cosmetics (
+		child_id INTEGER NOT NULL,
+		item_key TEXT NOT NULL,
+		slot TEXT NOT NULL,
+		equipped BOOLEAN NOT NULL DEFAULT 0,
+		acquired_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (child_id, item_key),
+		FOREIGN KEY (child_id) REFERENCES children(id)
+	);`
+
+	_, err = DB.Exec(createChildCosmeticsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createBountiesTable := `
+	CREATE TABLE IF NOT EXISTS bounties (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		chore_id INTEGER NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		max_claims INTEGER NOT NULL DEFAULT 0,
+		is_expired BOOLEAN NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id),
+		FOREIGN KEY (chore_id) REFERENCES chores(id)
+	);`
+
+	_, err = DB.Exec(createBountiesTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createBountyClaimsTable := `
+	CREATE TABLE IF NOT EXISTS bounty_claims (
+		bounty_id INTEGER NOT NULL,
+		child_id INTEGER NOT NULL,
+		claimed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (bounty_id, child_id),
+		FOREIGN KEY (bounty_id) REFERENCES bounties(id),
+		FOREIGN KEY (child_id) REFERENCES children(id)
+	);`
+
+	_, err = DB.Exec(createBountyClaimsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createSwapRulesTable := `
+	CREATE TABLE IF NOT EXISTS swap_rules (
+		user_id INTEGER PRIMARY KEY,
+		require_approval BOOLEAN NOT NULL DEFAULT 0,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);`
+
+	_, err = DB.Exec(createSwapRulesTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createChoreSwapsTable := `
+	CREATE TABLE IF NOT EXISTS chore_swaps (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		from_child_id INTEGER NOT NULL,
+		to_child_id INTEGER NOT NULL,
+		offered_assignment_id INTEGER NOT NULL,
+		offered_chore TEXT NOT NULL,
+		requested_assignment_id INTEGER NOT NULL DEFAULT 0,
+		requested_chore TEXT NOT NULL DEFAULT '',
+		points INTEGER NOT NULL DEFAULT 0,
+		note TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		resolved_at TIMESTAMP,
+		FOREIGN KEY (from_child_id) REFERENCES children(id),
+		FOREIGN KEY (to_child_id) REFERENCES children(id)
+	);`
+
+	_, err = DB.Exec(createChoreSwapsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createDigestsSentTable := `
+	CREATE TABLE IF NOT EXISTS digests_sent (
+		user_id INTEGER NOT NULL,
+		week_start TEXT NOT NULL,
+		sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, week_start),
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);`
+
+	_, err = DB.Exec(createDigestsSentTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Columns added after the original tables were created
+	addColumn("children", "experience", "INTEGER NOT NULL DEFAULT 0")
+	addColumn("chores", "category", "TEXT NOT NULL DEFAULT ''")
+	addColumn("chores", "tags", "TEXT NOT NULL DEFAULT ''")
+	addColumn("chores", "icon", "TEXT NOT NULL DEFAULT ''")
+	addColumn("chores", "partial_points", "BOOLEAN NOT NULL DEFAULT 0")
+	addColumn("chore_templates", "category", "TEXT NOT NULL DEFAULT ''")
+	addColumn("children", "birthdate", "TEXT NOT NULL DEFAULT ''")
+	addColumn("rewards", "min_age", "INTEGER NOT NULL DEFAULT 0")
+	addColumn("rewards", "max_age", "INTEGER NOT NULL DEFAULT 0")
+	addColumn("rewards", "stock", "INTEGER NOT NULL DEFAULT 0")
+	addColumn("rewards", "stock_period", "TEXT NOT NULL DEFAULT ''")
+	addColumn("rewards", "cooldown_hours", "INTEGER NOT NULL DEFAULT 0")
+	addColumn("rewards", "child_limit", "INTEGER NOT NULL DEFAULT 0")
+	addColumn("rewards", "child_limit_period", "TEXT NOT NULL DEFAULT ''")
+	addColumn("rewards", "available_days", "TEXT NOT NULL DEFAULT ''")
+	addColumn("rewards", "available_from", "TEXT NOT NULL DEFAULT ''")
+	addColumn("rewards", "available_until", "TEXT NOT NULL DEFAULT ''")
+	addColumn("point_transactions", "category", "TEXT NOT NULL DEFAULT ''")
+	addColumn("rewards", "requires_chores", "BOOLEAN NOT NULL DEFAULT 0")
+	addColumn("chores", "difficulty", "INTEGER NOT NULL DEFAULT 0")
+	addColumn("chores", "minutes", "INTEGER NOT NULL DEFAULT 0")
+	addColumn("assignments", "due_date", "TEXT NOT NULL DEFAULT ''")
+	addColumn("assignments", "recurrence", "TEXT NOT NULL DEFAULT ''")
+	addColumn("chores", "is_bounty", "BOOLEAN NOT NULL DEFAULT 0")
+	addColumn("assignments", "completed_at", "TIMESTAMP")
+	addColumn("assignments", "approved_at", "TIMESTAMP")
+	addColumn("assignments", "approved_by", "INTEGER")
+	addColumn("assignments", "points_awarded", "INTEGER NOT NULL DEFAULT 0")
+	addColumn("assignments", "chore_description", "TEXT NOT NULL DEFAULT ''")
+	addColumn("notification_preferences", "notify_digest", "BOOLEAN NOT NULL DEFAULT 1")
+
 	log.Println("Database tables initialized")
 }
+
+// function to add a column to an existing table if it is not already there
+func addColumn(table, column, definition string) {
+	rows, err := DB.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   bool
+			dfltValue sql.NullString
+			pk        int
+		)
+		err = rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if name == column {
+			return
+		}
+	}
+	if err = rows.Err(); err != nil {
+		log.Fatal(err)
+	}
+	rows.Close()
+
+	_, err = DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Added column %s.%s", table, column)
+}
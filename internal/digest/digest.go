@@ -0,0 +1,103 @@
+// Package digest builds the weekly summary of each child's progress, as a
+// printable page and as an email sent to parents every week.
+package digest
+
+import (
+	"Adven-Chores/internal/models"
+	"Adven-Chores/internal/notify"
+	"bytes"
+	"database/sql"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"time"
+)
+
+// the digest template, relative to the server's working directory like the
+// handlers' templates
+const templatePath = "../../templates/digest.html"
+
+// function to write a digest as an HTML page. Emailed digests leave out the
+// print button and the links to other weeks.
+func Render(w io.Writer, d *models.Digest, email bool) error {
+	tmpl, err := template.ParseFiles(templatePath)
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		Digest *models.Digest
+		Email  bool
+	}{
+		Digest: d,
+		Email:  email,
+	}
+
+	return tmpl.Execute(w, data)
+}
+
+// Sender emails each household's digest once a week
+type Sender struct {
+	DB     *sql.DB
+	Mailer notify.Mailer
+}
+
+func NewSender(db *sql.DB, mailer notify.Mailer) *Sender {
+	return &Sender{DB: db, Mailer: mailer}
+}
+
+// function to send the digest for the last full week to every parent who
+// wants it and hasn't had it yet
+func (s *Sender) SendDue(now time.Time) error {
+	week := models.DigestWeek(now)
+
+	recipients, err := models.GetDigestRecipients(s.DB, week)
+	if err != nil {
+		return err
+	}
+
+	for _, prefs := range recipients {
+		err := s.Send(prefs.UserID, prefs.Email, week)
+		if err != nil {
+			// Keep going so one bad address doesn't hold up everyone else
+			log.Printf("digest: user %d: %v", prefs.UserID, err)
+			continue
+		}
+
+		err = models.RecordDigestSent(s.DB, prefs.UserID, week)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// function to email a household's digest for the week starting on week
+func (s *Sender) Send(userID int, to string, week time.Time) error {
+	d, err := models.GetDigest(s.DB, userID, week)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	err = Render(&body, d, true)
+	if err != nil {
+		return fmt.Errorf("failed to render digest: %v", err)
+	}
+
+	subject := fmt.Sprintf("Family progress for the week of %s", d.Start.Format("Jan 2"))
+	return s.Mailer.Send(to, subject, "text/html; charset=UTF-8", body.String())
+}
+
+// Run calls SendDue on the given interval until the process exits
+func (s *Sender) Run(interval time.Duration) {
+	for {
+		err := s.SendDue(time.Now())
+		if err != nil {
+			log.Printf("digest: %v", err)
+		}
+		time.Sleep(interval)
+	}
+}
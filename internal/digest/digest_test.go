@@ -0,0 +1,163 @@
+package digest
+
+import (
+	"Adven-Chores/internal/database"
+	"Adven-Chores/internal/models"
+	"Adven-Chores/internal/notify"
+	"Adven-Chores/internal/notify/smtptest"
+	"database/sql"
+	"fmt"
+	"io"
+	"mime"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// function to open a fresh database in a temporary home directory
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	db, err := database.InitDB()
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// function to add a parent with one child and their notification preferences
+func addHousehold(t *testing.T, db *sql.DB, name, email string, digest bool) {
+	t.Helper()
+
+	result, err := db.Exec("INSERT INTO users (username, email, password_hash) VALUES (?, ?, '')", name, email)
+	if err != nil {
+		t.Fatalf("failed to add user: %v", err)
+	}
+	userID, _ := result.LastInsertId()
+
+	child := &models.Child{UserID: int(userID), Name: name + "'s kid"}
+	if err := child.Save(db); err != nil {
+		t.Fatalf("failed to add child: %v", err)
+	}
+
+	prefs := &models.NotificationPreferences{UserID: int(userID), NotifyDigest: digest, EmailEnabled: true, Email: email}
+	if err := prefs.Save(db); err != nil {
+		t.Fatalf("failed to save preferences: %v", err)
+	}
+}
+
+func TestSendDueEmailsEachDigestOnce(t *testing.T) {
+	db := testDB(t)
+	addHousehold(t, db, "alex", "alex@example.com", true)
+	addHousehold(t, db, "blair", "blair@example.com", false)
+
+	server := smtptest.NewServer()
+	defer server.Close()
+	sender := NewSender(db, notify.NewSMTPMailer(server.Addr, "chores@example.com", "", ""))
+
+	now := time.Date(2026, 10, 21, 9, 0, 0, 0, time.Local)
+	if err := sender.SendDue(now); err != nil {
+		t.Fatalf("SendDue: %v", err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	if len(messages[0].To) != 1 || messages[0].To[0] != "alex@example.com" {
+		t.Errorf("to = %v, want [alex@example.com]", messages[0].To)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(messages[0].Data))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	if subject := msg.Header.Get("Subject"); subject != "Family progress for the week of Oct 12" {
+		t.Errorf("subject = %q", subject)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(msg.Header.Get("Content-Type")); mediaType != "text/html" {
+		t.Errorf("content type = %q, want text/html", mediaType)
+	}
+
+	body, err := io.ReadAll(msg.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	// html/template escapes the apostrophe in the child's name
+	if !strings.Contains(string(body), "alex&#39;s kid") {
+		t.Errorf("digest doesn't mention the child:\n%s", body)
+	}
+
+	// Later in the same week the digest has already been sent
+	if err := sender.SendDue(now.Add(2 * time.Hour)); err != nil {
+		t.Fatalf("SendDue: %v", err)
+	}
+	if got := len(server.Messages()); got != 1 {
+		t.Errorf("got %d messages after sending again, want 1", got)
+	}
+
+	// The next week's digest goes out as usual
+	if err := sender.SendDue(now.AddDate(0, 0, 7)); err != nil {
+		t.Fatalf("SendDue: %v", err)
+	}
+	if got := len(server.Messages()); got != 2 {
+		t.Errorf("got %d messages the following week, want 2", got)
+	}
+}
+
+func TestSendDueRetriesFailedDigests(t *testing.T) {
+	db := testDB(t)
+	addHousehold(t, db, "alex", "alex@example.com", true)
+
+	// Nothing is listening on the address of a closed server
+	down := smtptest.NewServer()
+	down.Close()
+
+	now := time.Date(2026, 10, 21, 9, 0, 0, 0, time.Local)
+	err := NewSender(db, notify.NewSMTPMailer(down.Addr, "chores@example.com", "", "")).SendDue(now)
+	if err != nil {
+		t.Fatalf("SendDue: %v", err)
+	}
+
+	recipients, err := models.GetDigestRecipients(db, models.DigestWeek(now))
+	if err != nil {
+		t.Fatalf("GetDigestRecipients: %v", err)
+	}
+	if len(recipients) != 1 {
+		t.Fatalf("a failed digest was recorded as sent")
+	}
+
+	server := smtptest.NewServer()
+	defer server.Close()
+
+	err = NewSender(db, notify.NewSMTPMailer(server.Addr, "chores@example.com", "", "")).SendDue(now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("SendDue: %v", err)
+	}
+	if got := len(server.Messages()); got != 1 {
+		t.Errorf("got %d messages on retry, want 1", got)
+	}
+}
+
+func TestRenderLeavesOutLinksInEmail(t *testing.T) {
+	d := &models.Digest{Start: time.Date(2026, 10, 12, 0, 0, 0, 0, time.Local)}
+
+	var page, email strings.Builder
+	if err := Render(&page, d, false); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if err := Render(&email, d, true); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	previous := fmt.Sprintf("/digest?week=%s", d.Previous().Format(models.DueDateLayout))
+	if !strings.Contains(page.String(), previous) {
+		t.Errorf("page is missing the link to the previous week")
+	}
+	if strings.Contains(email.String(), previous) {
+		t.Errorf("email links to the previous week")
+	}
+}
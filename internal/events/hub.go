@@ -0,0 +1,115 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Domain event types published by the handlers
+const (
+	AssignmentCompleted = "assignment.completed"
+	AssignmentRewarded  = "assignment.rewarded"
+	RewardRedeemed      = "reward.redeemed"
+	ChoreAdded          = "chore.added"
+	ChildLevelUp        = "child.level_up"
+	CashOutRequested    = "cash_out.requested"
+	PointsAdjusted      = "points.adjusted"
+	CompetitionClosed   = "competition.closed"
+	QuestCompleted      = "quest.completed"
+	BountyPosted        = "bounty.posted"
+	BountyClaimed       = "bounty.claimed"
+	BountyExpired       = "bounty.expired"
+	SwapProposed        = "swap.proposed"
+	SwapCompleted       = "swap.completed"
+
+	// GoalAffordable is only used as a notification kind
+	GoalAffordable = "goal.affordable"
+)
+
+// Event describes something that happened in a household. UserID is the
+// parent account the event belongs to and is used to route it to subscribers.
+type Event struct {
+	Type         string    `json:"type"`
+	UserID       int       `json:"-"`
+	ChildID      int64     `json:"child_id,omitempty"`
+	ChoreID      int64     `json:"chore_id,omitempty"`
+	AssignmentID int64     `json:"assignment_id,omitempty"`
+	RewardID     int64     `json:"reward_id,omitempty"`
+	ChildName    string    `json:"child_name,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	Points       int       `json:"points,omitempty"`
+	Level        int       `json:"level,omitempty"`
+	Time         time.Time `json:"time"`
+}
+
+// Hub is an in-process publish/subscribe hub for household events
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[int]map[chan Event]struct{}
+	listeners   []func(Event)
+}
+
+// size of each subscriber's buffer; events are dropped for slow subscribers
+const subscriberBuffer = 16
+
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[int]map[chan Event]struct{})}
+}
+
+// function to subscribe to the events of a household; the returned function
+// must be called to release the subscription
+func (h *Hub) Subscribe(userID int) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan Event]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[userID], ch)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// function to register a listener that receives every event from every
+// household; listeners run on the publisher's goroutine and must not block
+func (h *Hub) AddListener(fn func(Event)) {
+	h.mu.Lock()
+	h.listeners = append(h.listeners, fn)
+	h.mu.Unlock()
+}
+
+// function to publish an event to every subscriber of its household
+func (h *Hub) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, fn := range h.listeners {
+		fn(e)
+	}
+
+	for ch := range h.subscribers[e.UserID] {
+		select {
+		case ch <- e:
+		default:
+			// subscriber is not keeping up; drop rather than block the publisher
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/events"
+	"Adven-Chores/internal/models"
+	"Adven-Chores/internal/notify"
+	"database/sql"
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slate20/goauth"
+)
+
+// how far back the bonuses and penalties report goes
+const adjustmentReportDays = 30
+
+func AdjustPointsHandler(db *sql.DB, auth *goauth.AuthService, hub *events.Hub, notifier *notify.Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			renderAdjustPoints(w, child, "")
+		} else if r.Method == http.MethodPost {
+			points, err := strconv.Atoi(r.FormValue("points"))
+			if err != nil || points <= 0 {
+				http.Error(w, "Invalid points value", http.StatusBadRequest)
+				return
+			}
+			if r.FormValue("type") == models.PointsPenalty {
+				points = -points
+			}
+
+			category := r.FormValue("category")
+			if category != "" && !slices.Contains(models.AdjustmentCategories, category) {
+				http.Error(w, "Invalid category", http.StatusBadRequest)
+				return
+			}
+
+			reason := strings.TrimSpace(r.FormValue("reason"))
+			err = models.AdjustPoints(db, child, points, category, reason)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			hub.Publish(events.Event{
+				Type:        events.PointsAdjusted,
+				UserID:      userID,
+				ChildID:     child.ID,
+				ChildName:   child.Name,
+				Description: reason,
+				Points:      points,
+			})
+
+			// A bonus can make a savings goal affordable, a penalty put it out of reach
+			checkSavingsGoal(db, notifier, child)
+
+			applied := fmt.Sprintf("Gave %s a bonus of %d points", child.Name, points)
+			if points < 0 {
+				applied = fmt.Sprintf("Took %d points from %s", -points, child.Name)
+			}
+
+			// Trigger refresh and keep the form open for further adjustments
+			w.Header().Set("HX-Trigger", "refreshList, refreshAdjustments")
+			renderAdjustPoints(w, child, applied)
+		}
+	}
+}
+
+// function to render the bonus/penalty form for a child, with a note about
+// the adjustment that was just applied
+func renderAdjustPoints(w http.ResponseWriter, child *models.Child, applied string) {
+	data := struct {
+		Child      *models.Child
+		Categories []string
+		Applied    string
+	}{
+		Child:      child,
+		Categories: models.AdjustmentCategories,
+		Applied:    applied,
+	}
+
+	tmpl, err := template.ParseFiles("../../templates/adjust_points.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func AdjustmentListHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		report, err := loadAdjustmentReport(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		tmpl, err := template.ParseFiles("../../templates/adjustment_list.html")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = tmpl.Execute(w, report)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// data for the bonuses and penalties report
+type adjustmentReport struct {
+	Days        int
+	Totals      []*models.AdjustmentTotals
+	Adjustments []*models.PointTransaction
+}
+
+// function to load the recent bonuses and penalties of a household
+func loadAdjustmentReport(db *sql.DB, userID int) (*adjustmentReport, error) {
+	adjustments, err := models.GetAdjustments(db, userID, time.Now().AddDate(0, 0, -adjustmentReportDays))
+	if err != nil {
+		return nil, err
+	}
+
+	return &adjustmentReport{
+		Days:        adjustmentReportDays,
+		Totals:      models.TotalAdjustments(adjustments),
+		Adjustments: adjustments,
+	}, nil
+}
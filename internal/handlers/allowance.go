@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/events"
+	"Adven-Chores/internal/models"
+	"Adven-Chores/internal/notify"
+	"database/sql"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/slate20/goauth"
+)
+
+// number of cash outs shown in the parent panel
+const cashOutListSize = 25
+
+// number of point transactions shown on the child dashboard
+const pointHistorySize = 20
+
+const allowanceSettingsButton = `<button class="action-button" hx-get="/allowance-settings" hx-target="#allowance-action-container" hx-swap="innerHTML">Allowance Settings</button>`
+
+// data for the parent's allowance overview
+type allowanceListData struct {
+	Settings  *models.AllowanceSettings
+	Children  []*models.Child
+	CashOuts  []*models.CashOut
+	Owed      map[int64]int
+	TotalOwed int
+	Methods   []string
+}
+
+// function to load the parent's allowance overview
+func loadAllowanceList(db *sql.DB, userID int) (*allowanceListData, error) {
+	settings, err := models.GetAllowanceSettings(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	children, err := models.GetChildrenByUserID(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cashOuts, err := models.GetCashOutsByUserID(db, userID, cashOutListSize)
+	if err != nil {
+		return nil, err
+	}
+
+	owed, err := models.GetMoneyOwed(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, cents := range owed {
+		total += cents
+	}
+
+	return &allowanceListData{
+		Settings:  settings,
+		Children:  children,
+		CashOuts:  cashOuts,
+		Owed:      owed,
+		TotalOwed: total,
+		Methods:   models.PayoutMethods,
+	}, nil
+}
+
+// function to render the parent's allowance overview
+func renderAllowanceList(w http.ResponseWriter, db *sql.DB, userID int) {
+	data, err := loadAllowanceList(db, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl, err := template.ParseFiles("../../templates/allowance_list.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// function to load a cash out from the last path segment and check it belongs to the user
+func userCashOut(db *sql.DB, r *http.Request, userID int) (*models.CashOut, int, error) {
+	paths := strings.Split(r.URL.Path, "/")
+	id, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("Invalid cash out ID")
+	}
+
+	cashOut, err := models.GetCashOutByID(db, id)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if userID != cashOut.UserID {
+		return nil, http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	return cashOut, http.StatusOK, nil
+}
+
+func AllowanceListHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		renderAllowanceList(w, db, userID)
+	}
+}
+
+func AllowanceSettingsHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		settings, err := models.GetAllowanceSettings(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			tmpl, err := template.ParseFiles("../../templates/allowance_settings.html")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			err = tmpl.Execute(w, settings)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			settings.Enabled = r.FormValue("enabled") == "on"
+			settings.PointsPerUnit, _ = strconv.Atoi(r.FormValue("points_per_unit"))
+			settings.Currency = strings.TrimSpace(r.FormValue("currency"))
+			settings.MinCashOut, _ = strconv.Atoi(r.FormValue("min_cash_out"))
+			if settings.Currency == "" {
+				settings.Currency = "$"
+			}
+
+			err = settings.Save(db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("HX-Trigger", "refreshAllowance")
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(allowanceSettingsButton))
+		}
+	}
+}
+
+func AllowanceActionHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(allowanceSettingsButton))
+	}
+}
+
+// function for a child to ask to turn points into money
+func CashOutHandler(db *sql.DB, auth *goauth.AuthService, hub *events.Hub, notifier *notify.Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		settings, err := models.GetAllowanceSettings(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !settings.Enabled {
+			http.Error(w, "Allowance mode is turned off", http.StatusBadRequest)
+			return
+		}
+
+		points, err := strconv.Atoi(r.FormValue("points"))
+		if err != nil || points <= 0 {
+			http.Error(w, "Invalid number of points", http.StatusBadRequest)
+			return
+		}
+		if points < settings.MinCashOut {
+			http.Error(w, fmt.Sprintf("You need to cash out at least %d points", settings.MinCashOut), http.StatusBadRequest)
+			return
+		}
+
+		// Points locked for a savings goal can't be cashed out
+		cashOut, err := models.RequestCashOut(db, child, points, settings)
+		if err == models.ErrNotEnoughPoints {
+			http.Error(w, "Not enough points", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		notifier.Dispatch(&models.Notification{
+			UserID:  userID,
+			ChildID: child.ID,
+			Kind:    events.CashOutRequested,
+			Title:   "Cash out requested",
+			Body:    fmt.Sprintf("%s wants to cash out %d points for %s", child.Name, points, settings.Format(cashOut.AmountCents)),
+		})
+
+		hub.Publish(events.Event{
+			Type:      events.CashOutRequested,
+			UserID:    userID,
+			ChildID:   child.ID,
+			ChildName: child.Name,
+			Points:    points,
+		})
+
+		w.Header().Set("HX-Trigger", "refreshChildDashboard")
+		w.Header().Set("Content-Type", "text/html")
+	}
+}
+
+func ApproveCashOutHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		cashOut, status, err := userCashOut(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		err = models.ApproveCashOut(db, cashOut.ID)
+		if err == models.ErrCashOutClosed {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		renderAllowanceList(w, db, userID)
+	}
+}
+
+func PayCashOutHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		cashOut, status, err := userCashOut(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		method := r.FormValue("method")
+		if method == "" {
+			method = models.PayoutMethods[0]
+		}
+
+		err = models.PayCashOut(db, cashOut.ID, method, strings.TrimSpace(r.FormValue("note")))
+		if err == models.ErrCashOutClosed {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		renderAllowanceList(w, db, userID)
+	}
+}
+
+func RejectCashOutHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		cashOut, status, err := userCashOut(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		err = models.RejectCashOut(db, cashOut.ID, strings.TrimSpace(r.FormValue("note")))
+		if err == models.ErrCashOutClosed {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// The points went back to the child
+		w.Header().Set("HX-Trigger", "refreshList")
+		renderAllowanceList(w, db, userID)
+	}
+}
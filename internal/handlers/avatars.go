@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/models"
+	"database/sql"
+	"html/template"
+	"net/http"
+	"slices"
+
+	"github.com/slate20/goauth"
+)
+
+// function to render a child's avatar shop
+func renderAvatarShop(w http.ResponseWriter, db *sql.DB, child *models.Child) {
+	avatar, err := models.GetAvatar(db, child)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	spendable, err := models.GetSpendablePoints(db, child)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	items, err := models.GetCosmeticShop(db, child, spendable)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Avatar    *models.Avatar
+		Jobs      []*models.JobClass
+		Slots     []string
+		Items     []*models.ShopItem
+		Spendable int
+	}{
+		Avatar:    avatar,
+		Jobs:      models.JobClasses,
+		Slots:     models.CosmeticSlots,
+		Items:     items,
+		Spendable: spendable,
+	}
+
+	tmpl, err := template.ParseFiles("../../templates/avatar_shop.html", "../../templates/avatar.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// function to show a child's avatar with the job classes and cosmetic items
+func AvatarShopHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		renderAvatarShop(w, db, child)
+	}
+}
+
+// function to change a child's job class
+func ChooseJobHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		err = models.SetJobClass(db, child, r.FormValue("job"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Refresh the child nav so the new avatar shows there too
+		w.Header().Set("HX-Trigger", "refreshList")
+		renderAvatarShop(w, db, child)
+	}
+}
+
+// function to buy a cosmetic item with a child's points
+func BuyCosmeticHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		item := models.GetCosmetic(r.FormValue("item"))
+		if item == nil {
+			http.Error(w, "Invalid item", http.StatusBadRequest)
+			return
+		}
+
+		err = models.BuyCosmetic(db, child, item)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		renderAvatarShop(w, db, child)
+	}
+}
+
+// function to wear a cosmetic item the child owns or has unlocked
+func EquipCosmeticHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		item := models.GetCosmetic(r.FormValue("item"))
+		if item == nil {
+			http.Error(w, "Invalid item", http.StatusBadRequest)
+			return
+		}
+
+		err = models.EquipCosmetic(db, child, item)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "refreshList")
+		renderAvatarShop(w, db, child)
+	}
+}
+
+// function to take off the item a child wears in a slot
+func UnequipCosmeticHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		slot := r.FormValue("slot")
+		if !slices.Contains(models.CosmeticSlots, slot) {
+			http.Error(w, "Invalid slot", http.StatusBadRequest)
+			return
+		}
+
+		err = models.UnequipCosmetic(db, child.ID, slot)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "refreshList")
+		renderAvatarShop(w, db, child)
+	}
+}
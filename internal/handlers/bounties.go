@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/events"
+	"Adven-Chores/internal/models"
+	"Adven-Chores/internal/notify"
+	"database/sql"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slate20/goauth"
+)
+
+const bountyButton = `<button class="action-button" hx-get="/add-bounty" hx-target="#bounty-action-container" hx-swap="innerHTML">Post Bounty</button>`
+
+// how long a new bounty stays up unless the parent picks another time
+const defaultBountyDays = 3
+
+// function to get a bounty from the last path segment, checking it belongs to the user
+func pathBounty(db *sql.DB, r *http.Request, userID int) (*models.Bounty, int, error) {
+	paths := strings.Split(r.URL.Path, "/")
+	id, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("Invalid bounty ID")
+	}
+
+	bounty, err := models.GetBountyByID(db, id)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if userID != bounty.UserID {
+		return nil, http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	return bounty, http.StatusOK, nil
+}
+
+func BountyListHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		bounties, err := models.GetBountiesByUserID(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		data := struct {
+			Bounties []*models.Bounty
+			Now      time.Time
+		}{
+			Bounties: bounties,
+			Now:      time.Now(),
+		}
+
+		tmpl, err := template.ParseFiles("../../templates/bounty_list.html")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = tmpl.Execute(w, data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func AddBountyHandler(db *sql.DB, auth *goauth.AuthService, hub *events.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			data := struct {
+				ExpiresAt string
+				Icons     []string
+			}{
+				ExpiresAt: time.Now().AddDate(0, 0, defaultBountyDays).Format(models.BountyExpiryLayout),
+				Icons:     models.ChoreIcons,
+			}
+
+			tmpl, err := template.ParseFiles("../../templates/add_bounty.html")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			err = tmpl.Execute(w, data)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			expiresAt, err := time.ParseInLocation(models.BountyExpiryLayout, r.FormValue("expires_at"), time.Local)
+			if err != nil {
+				http.Error(w, "Invalid expiry time", http.StatusBadRequest)
+				return
+			}
+
+			points, _ := strconv.Atoi(r.FormValue("points"))
+			maxClaims, _ := strconv.Atoi(r.FormValue("max_claims"))
+			bounty := &models.Bounty{
+				UserID: userID,
+				Chore: &models.Chore{
+					Description: r.FormValue("description"),
+					Points:      points,
+					Icon:        r.FormValue("icon"),
+				},
+				ExpiresAt: expiresAt,
+				MaxClaims: maxClaims,
+			}
+
+			err = bounty.Save(db, time.Now())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			hub.Publish(events.Event{
+				Type:        events.BountyPosted,
+				UserID:      userID,
+				ChoreID:     bounty.Chore.ID,
+				Description: bounty.Chore.Description,
+				Points:      bounty.Chore.Points,
+			})
+
+			// Trigger refresh and return the Post Bounty button
+			w.Header().Set("HX-Trigger", "refreshBounties")
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(bountyButton))
+		}
+	}
+}
+
+func DeleteBountyHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		bounty, status, err := pathBounty(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		err = models.DeleteBounty(db, bounty)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "refreshBounties, refreshAssignments")
+	}
+}
+
+func BountyActionHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(bountyButton))
+	}
+}
+
+// function to show the open bounties on a child's dashboard
+func ChildBountiesHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		bounties, err := models.GetOpenBounties(db, userID, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		data := struct {
+			Child    *models.Child
+			Bounties []*models.Bounty
+		}{
+			Child:    child,
+			Bounties: bounties,
+		}
+
+		tmpl, err := template.ParseFiles("../../templates/child_bounties.html")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = tmpl.Execute(w, data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// function to claim a bounty for a child, adding its chore to their list
+func ClaimBountyHandler(db *sql.DB, auth *goauth.AuthService, hub *events.Hub, notifier *notify.Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		bounty, status, err := pathBounty(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		childID, err := strconv.ParseInt(r.FormValue("child_id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid child ID", http.StatusBadRequest)
+			return
+		}
+
+		child, err := models.GetChildByID(db, childID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if userID != child.UserID {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		err = models.ClaimBounty(db, bounty, child, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		notifier.Dispatch(&models.Notification{
+			UserID:  userID,
+			ChildID: child.ID,
+			Kind:    events.BountyClaimed,
+			Title:   "Bounty claimed",
+			Body:    fmt.Sprintf("%s claimed \"%s\" for %d points", child.Name, bounty.Chore.Description, bounty.Chore.Points),
+		})
+
+		hub.Publish(events.Event{
+			Type:        events.BountyClaimed,
+			UserID:      userID,
+			ChildID:     child.ID,
+			ChoreID:     bounty.Chore.ID,
+			ChildName:   child.Name,
+			Description: bounty.Chore.Description,
+			Points:      bounty.Chore.Points,
+		})
+
+		w.Header().Set("HX-Trigger", "refreshBounties, refreshChildDashboard")
+	}
+}
+
+// function to take expired bounties off the board and let the household
+// know; main runs it periodically
+func ExpireBounties(db *sql.DB, hub *events.Hub, notifier *notify.Dispatcher, now time.Time) {
+	expired, err := models.ExpireBounties(db, now)
+	if err != nil {
+		log.Printf("ExpireBounties: %v", err)
+	}
+	for _, b := range expired {
+		announceBountyExpired(hub, notifier, b)
+	}
+}
+
+// function to let the household know a bounty has come off the board
+func announceBountyExpired(hub *events.Hub, notifier *notify.Dispatcher, b *models.Bounty) {
+	if len(b.Claims) == 0 {
+		notifier.Dispatch(&models.Notification{
+			UserID: b.UserID,
+			Kind:   events.BountyExpired,
+			Title:  "Bounty expired",
+			Body:   fmt.Sprintf("Nobody claimed \"%s\" before it expired", b.Chore.Description),
+		})
+	}
+
+	hub.Publish(events.Event{
+		Type:        events.BountyExpired,
+		UserID:      b.UserID,
+		ChoreID:     b.Chore.ID,
+		Description: b.Chore.Description,
+		Points:      b.Chore.Points,
+	})
+}
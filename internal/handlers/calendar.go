@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/calendar"
+	"Adven-Chores/internal/models"
+	"database/sql"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slate20/goauth"
+)
+
+// function to get the week asked for with ?week=YYYY-MM-DD, defaulting to this week
+func requestedWeek(r *http.Request) time.Time {
+	week, err := time.ParseInLocation(models.DueDateLayout, r.URL.Query().Get("week"), time.Local)
+	if err != nil {
+		return time.Now()
+	}
+	return week
+}
+
+// function to render a week of assignments. url is where the previous and
+// next week buttons load from.
+func renderCalendar(w http.ResponseWriter, r *http.Request, assignments []*models.Assignment, url string, showChild bool) {
+	data := struct {
+		Week      *calendar.Week
+		URL       string
+		ShowChild bool
+	}{
+		Week:      calendar.BuildWeek(assignments, requestedWeek(r), time.Now()),
+		URL:       url,
+		ShowChild: showChild,
+	}
+
+	tmpl, err := template.ParseFiles("../../templates/calendar.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// function to show every child's assignments on a weekly calendar
+func CalendarHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		assignments, err := models.GetAssignmentsByUserID(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		renderCalendar(w, r, assignments, "/calendar", true)
+	}
+}
+
+// function to show one child's assignments on a weekly calendar
+func ChildCalendarHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		assignments, err := models.GetAssignmentsByChild(db, child.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		renderCalendar(w, r, assignments, fmt.Sprintf("/child-calendar/%d", child.ID), false)
+	}
+}
+
+const calendarFeedsButton = `<button class="action-button" hx-get="/calendar-feeds" hx-target="#calendar-feed-container" hx-swap="innerHTML">Calendar Feeds</button>`
+
+func CalendarActionHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(calendarFeedsButton))
+	}
+}
+
+// a calendar feed link shown in the parent panel
+type feedLink struct {
+	ChildID int64
+	Name    string
+	URL     string
+}
+
+// function to build the absolute address of a feed from the request
+func feedURL(r *http.Request, feed *models.CalendarFeed) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/ics/%s.ics", scheme, r.Host, feed.Token)
+}
+
+// function to render the household and per-child feed links
+func renderCalendarFeeds(w http.ResponseWriter, r *http.Request, db *sql.DB, userID int) {
+	children, err := models.GetChildrenByUserID(db, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	feed, err := models.GetCalendarFeed(db, userID, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	links := []feedLink{{Name: "Whole family", URL: feedURL(r, feed)}}
+
+	for _, child := range children {
+		feed, err := models.GetCalendarFeed(db, userID, child.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		links = append(links, feedLink{ChildID: child.ID, Name: child.Name, URL: feedURL(r, feed)})
+	}
+
+	tmpl, err := template.ParseFiles("../../templates/calendar_feeds.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, links)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func CalendarFeedsHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		renderCalendarFeeds(w, r, db, userID)
+	}
+}
+
+// function to replace a feed's link, e.g. after it was shared by mistake.
+// Child ID 0 resets the household feed.
+func ResetCalendarFeedHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		paths := strings.Split(r.URL.Path, "/")
+		childID, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid child ID", http.StatusBadRequest)
+			return
+		}
+
+		if childID != 0 {
+			if _, status, err := pathChild(db, r, userID); err != nil {
+				http.Error(w, err.Error(), status)
+				return
+			}
+		}
+
+		_, err = models.ResetCalendarFeed(db, userID, childID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		renderCalendarFeeds(w, r, db, userID)
+	}
+}
+
+// function to load the name and assignments of a feed
+func feedAssignments(db *sql.DB, feed *models.CalendarFeed) (string, []*models.Assignment, error) {
+	if feed.ChildID == 0 {
+		assignments, err := models.GetAssignmentsByUserID(db, feed.UserID)
+		return "Family Chores", assignments, err
+	}
+
+	child, err := models.GetChildByID(db, feed.ChildID)
+	if err != nil {
+		return "", nil, err
+	}
+	if child.UserID != feed.UserID {
+		return "", nil, fmt.Errorf("feed does not belong to the child's household")
+	}
+
+	assignments, err := models.GetAssignmentsByChild(db, child.ID)
+	return child.Name + "'s Chores", assignments, err
+}
+
+// function to serve a household or child feed to calendar apps. The
+// token in the address is the only credential, so this route is public.
+func ICSFeedHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		paths := strings.Split(r.URL.Path, "/")
+		token := strings.TrimSuffix(paths[len(paths)-1], ".ics")
+
+		feed, err := models.GetCalendarFeedByToken(db, token)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		name, assignments, err := feedAssignments(db, feed)
+		if err != nil {
+			log.Printf("calendar feed: %v", err)
+			http.Error(w, "Failed to load calendar", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="chores.ics"`)
+		err = calendar.WriteICS(w, name, assignments, time.Now())
+		if err != nil {
+			log.Printf("calendar feed: %v", err)
+		}
+	}
+}
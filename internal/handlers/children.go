@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/slate20/goauth"
 )
@@ -100,17 +101,106 @@ func ChildDashboardHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFun
 			}
 		}
 
+		filter := choreFilterFromRequest(r)
+
+		goal, err := models.GetSavingsGoal(db, child.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		history, err := models.GetPointHistory(db, child.ID, pointHistorySize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		allowance, err := models.GetAllowanceSettings(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var cashOuts []*models.CashOut
+		if allowance.Enabled {
+			cashOuts, err = models.GetCashOutsByChild(db, child.ID, 5)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		jarSettings, err := models.GetJarSettings(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var jars *models.Jars
+		var transfers []*models.JarTransfer
+		if jarSettings.Enabled {
+			jars, err = models.GetJars(db, child)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			transfers, err = models.GetJarTransfers(db, child.ID, jarTransferListSize)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		spendable, err := models.LimitToSpendJar(db, child, models.SpendablePoints(child, goal, 0))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		avatar, err := models.GetAvatar(db, child)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
 		data := struct {
 			Child           *models.Child
+			Avatar          *models.Avatar
 			Assignments     []*models.Assignment
-			AvailableChores []*models.Chore
+			AvailableChores []*models.ChoreGroup
+			Categories      []string
+			Filter          models.ChoreFilter
+			Goal            *models.SavingsGoal
+			LockedPoints    int
+			History         []*models.PointTransaction
+			Allowance       *models.AllowanceSettings
+			CashOuts        []*models.CashOut
+			Spendable       int
+			JarSettings     *models.JarSettings
+			Jars            *models.Jars
+			JarTransfers    []*models.JarTransfer
+			JarNames        []string
 		}{
 			Child:           child,
+			Avatar:          avatar,
 			Assignments:     childAssignments,
-			AvailableChores: availableChores,
+			AvailableChores: models.GroupChoresByCategory(models.FilterChores(availableChores, filter)),
+			Categories:      models.ChoreCategories(availableChores),
+			Filter:          filter,
+			Goal:            goal,
+			LockedPoints:    goal.Locked(child.Points),
+			History:         history,
+			Allowance:       allowance,
+			CashOuts:        cashOuts,
+			Spendable:       spendable,
+			JarSettings:     jarSettings,
+			Jars:            jars,
+			JarTransfers:    transfers,
+			JarNames:        models.JarNames,
 		}
 
-		tmpl, err := template.ParseFiles("../../templates/child_dashboard.html")
+		tmpl, err := template.ParseFiles("../../templates/child_dashboard.html", "../../templates/avatar.html")
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -124,6 +214,15 @@ func ChildDashboardHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFun
 	}
 }
 
+// function to read an optional birthdate, ignoring anything that isn't a date
+func birthdateFromForm(r *http.Request) string {
+	birthdate := r.FormValue("birthdate")
+	if _, err := time.Parse(models.BirthdateLayout, birthdate); err != nil {
+		return ""
+	}
+	return birthdate
+}
+
 // Function to add a new child
 func AddChildHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -143,9 +242,10 @@ func AddChildHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 		} else if r.Method == http.MethodPost {
 			name := r.FormValue("name")
 			child := &models.Child{
-				UserID: userID,
-				Name:   name,
-				Points: 0,
+				UserID:    userID,
+				Name:      name,
+				Points:    0,
+				Birthdate: birthdateFromForm(r),
 			}
 			err := child.Save(db)
 			if err != nil {
@@ -203,9 +303,11 @@ func EditChildHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 				return
 			}
 		} else if r.Method == http.MethodPost {
+			previousPoints := child.Points
 			child.Name = r.FormValue("name")
 			child.Points, err = strconv.Atoi(r.FormValue("points"))
 			child.Rewards = r.FormValue("rewards")
+			child.Birthdate = birthdateFromForm(r)
 			if err != nil {
 				http.Error(w, "Invalid points value", http.StatusBadRequest)
 				return
@@ -217,6 +319,14 @@ func EditChildHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 				return
 			}
 
+			// Keep the points history in step with manual edits
+			if diff := child.Points - previousPoints; diff != 0 {
+				err = models.RecordPoints(db, child, diff, models.PointsAdjusted, "Changed by a parent")
+				if err != nil {
+					log.Printf("EditChildHandler: %v", err)
+				}
+			}
+
 			// Trigger refresh and return the Add Child button
 			w.Header().Set("HX-Trigger", "refreshList")
 			w.Header().Set("Content-Type", "text/html")
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/models"
+	"database/sql"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/slate20/goauth"
+)
+
+// function to read a chore's difficulty and time estimate from a form
+func choreEffortFromForm(r *http.Request, chore *models.Chore) {
+	chore.Difficulty, _ = strconv.Atoi(r.FormValue("difficulty"))
+	if chore.Difficulty < 0 || chore.Difficulty >= len(models.DifficultyNames) {
+		chore.Difficulty = 0
+	}
+	chore.Minutes, _ = strconv.Atoi(r.FormValue("minutes"))
+	if chore.Minutes < 0 {
+		chore.Minutes = 0
+	}
+}
+
+// function to suggest points while the add/edit chore form is filled in
+func SuggestPointsHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		pricing, err := models.GetChorePricing(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		chore := &models.Chore{}
+		choreEffortFromForm(r, chore)
+
+		w.Header().Set("Content-Type", "text/html")
+		if suggested := pricing.Suggest(chore.Minutes, chore.Difficulty); suggested > 0 {
+			fmt.Fprintf(w, "💡 Suggested: %d points", suggested)
+		}
+	}
+}
+
+// function to render the rebalance tool with the household pricing formula
+func renderRebalance(w http.ResponseWriter, db *sql.DB, userID int) {
+	pricing, err := models.GetChorePricing(db, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	chores, err := models.GetChoresByUserID(db, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	balances, average := models.RebalanceChores(chores, pricing)
+
+	data := struct {
+		Pricing  *models.ChorePricing
+		Balances []*models.ChoreBalance
+		Average  float64
+	}{
+		Pricing:  pricing,
+		Balances: balances,
+		Average:  average,
+	}
+
+	tmpl, err := template.ParseFiles("../../templates/rebalance_chores.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func RebalanceChoresHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		renderRebalance(w, db, userID)
+	}
+}
+
+func ChorePricingHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		pricing := &models.ChorePricing{UserID: userID}
+		pricing.PointsPerMinute, _ = strconv.ParseFloat(r.FormValue("points_per_minute"), 64)
+		pricing.MediumMultiplier, _ = strconv.ParseFloat(r.FormValue("medium_multiplier"), 64)
+		pricing.HardMultiplier, _ = strconv.ParseFloat(r.FormValue("hard_multiplier"), 64)
+
+		err = pricing.Save(db)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		renderRebalance(w, db, userID)
+	}
+}
+
+// function to set a chore's points to the suggested value from the rebalance tool
+func ApplySuggestedPointsHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		paths := strings.Split(r.URL.Path, "/")
+		id, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid chore ID", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		chore, err := models.GetChoreByID(db, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if userID != chore.UserID {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		pricing, err := models.GetChorePricing(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		suggested := pricing.Suggest(chore.Minutes, chore.Difficulty)
+		if suggested == 0 {
+			http.Error(w, "This chore has no time estimate", http.StatusBadRequest)
+			return
+		}
+
+		chore.Points = suggested
+		err = chore.Save(db)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "refreshChoreList")
+		renderRebalance(w, db, userID)
+	}
+}
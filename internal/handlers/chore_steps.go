@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/models"
+	"database/sql"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/slate20/goauth"
+)
+
+// function to render a chore's checklist editor
+func renderChoreSteps(w http.ResponseWriter, db *sql.DB, chore *models.Chore) {
+	steps, err := models.GetChoreSteps(db, chore.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Chore *models.Chore
+		Steps []*models.ChoreStep
+	}{
+		Chore: chore,
+		Steps: steps,
+	}
+
+	tmpl, err := template.ParseFiles("../../templates/chore_steps.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// function to load a step's chore and check it belongs to the user
+func stepChore(db *sql.DB, userID int, stepID int64) (*models.ChoreStep, *models.Chore, int, error) {
+	step, err := models.GetChoreStepByID(db, stepID)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, err
+	}
+
+	chore, err := models.GetChoreByID(db, step.ChoreID)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, err
+	}
+
+	if userID != chore.UserID {
+		return nil, nil, http.StatusUnauthorized, errors.New("Unauthorized")
+	}
+
+	return step, chore, http.StatusOK, nil
+}
+
+func ChoreStepsHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		paths := strings.Split(r.URL.Path, "/")
+		id, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid chore ID", http.StatusBadRequest)
+			return
+		}
+
+		chore, err := models.GetChoreByID(db, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Check if the userID matches the chore's userID and return Unauthorized if not
+		if userID != chore.UserID {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		renderChoreSteps(w, db, chore)
+	}
+}
+
+func AddChoreStepHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		paths := strings.Split(r.URL.Path, "/")
+		id, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid chore ID", http.StatusBadRequest)
+			return
+		}
+
+		chore, err := models.GetChoreByID(db, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Check if the userID matches the chore's userID and return Unauthorized if not
+		if userID != chore.UserID {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		description := strings.TrimSpace(r.FormValue("description"))
+		if description == "" {
+			http.Error(w, "Step description is required", http.StatusBadRequest)
+			return
+		}
+
+		step := &models.ChoreStep{
+			ChoreID:     chore.ID,
+			Description: description,
+			IsRequired:  r.FormValue("is_required") == "on",
+		}
+		err = step.Save(db)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		renderChoreSteps(w, db, chore)
+	}
+}
+
+func DeleteChoreStepHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		paths := strings.Split(r.URL.Path, "/")
+		id, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid step ID", http.StatusBadRequest)
+			return
+		}
+
+		_, chore, status, err := stepChore(db, userID, id)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		err = models.DeleteChoreStep(db, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		renderChoreSteps(w, db, chore)
+	}
+}
+
+func MoveChoreStepHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		paths := strings.Split(r.URL.Path, "/")
+		id, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid step ID", http.StatusBadRequest)
+			return
+		}
+
+		_, chore, status, err := stepChore(db, userID, id)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		delta := 1
+		if r.FormValue("direction") == "up" {
+			delta = -1
+		}
+
+		err = models.MoveChoreStep(db, id, delta)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		renderChoreSteps(w, db, chore)
+	}
+}
+
+// function for a child to tick or untick a checklist step of an assignment;
+// takes in assignmentID and stepID from the URL
+func ToggleAssignmentStepHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		paths := strings.Split(r.URL.Path, "/")
+		assignmentID, err := strconv.ParseInt(paths[len(paths)-2], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid assignment ID", http.StatusBadRequest)
+			return
+		}
+
+		stepID, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid step ID", http.StatusBadRequest)
+			return
+		}
+
+		assignment, err := models.GetAssignmentByID(db, assignmentID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// check if userID matches assignment.Chore.UserID and return Unauthorized if not
+		if userID != assignment.Chore.UserID {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if assignment.IsCompleted {
+			http.Error(w, "Assignment is already completed", http.StatusBadRequest)
+			return
+		}
+
+		step, err := models.GetChoreStepByID(db, stepID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if step.ChoreID != assignment.Chore.ID {
+			http.Error(w, "Step does not belong to this chore", http.StatusBadRequest)
+			return
+		}
+
+		err = models.SetAssignmentStep(db, assignmentID, stepID, r.FormValue("checked") == "on")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Refresh the child-dashboard to update progress and the Complete button
+		w.Header().Set("HX-Trigger", "refreshChildDashboard")
+		w.Header().Set("Content-Type", "text/html")
+	}
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/events"
+	"Adven-Chores/internal/models"
+	"database/sql"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/slate20/goauth"
+)
+
+// number of empty rows offered on the bulk form for chores not in a template
+const blankBulkRows = 3
+
+// function to show the bulk chore form and create the selected chores
+func BulkChoresHandler(db *sql.DB, auth *goauth.AuthService, hub *events.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		children, err := models.GetChildrenByUserID(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			age, _ := strconv.Atoi(r.URL.Query().Get("age"))
+
+			saved, err := models.GetChoreTemplatesByUserID(db, userID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			// Saved templates first, then the built-in catalogue, then blank rows
+			var rows []*models.ChoreTemplate
+			for _, t := range append(saved, models.BuiltinChoreTemplates...) {
+				if t.SuitsAge(age) {
+					rows = append(rows, t)
+				}
+			}
+			for i := 0; i < blankBulkRows; i++ {
+				rows = append(rows, &models.ChoreTemplate{})
+			}
+
+			data := struct {
+				Age       int
+				Ages      []int
+				Templates []*models.ChoreTemplate
+				Children  []*models.Child
+			}{
+				Age:       age,
+				Ages:      []int{3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+				Templates: rows,
+				Children:  children,
+			}
+
+			tmpl, err := template.ParseFiles("../../templates/bulk_chores.html")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			err = tmpl.Execute(w, data)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			ownChildren := make(map[int64]bool)
+			for _, child := range children {
+				ownChildren[child.ID] = true
+			}
+
+			count, _ := strconv.Atoi(r.FormValue("count"))
+			var batch []models.NewChore
+			for i := 0; i < count; i++ {
+				prefix := fmt.Sprintf("chore-%d-", i)
+				if r.FormValue(prefix+"selected") != "on" {
+					continue
+				}
+
+				description := strings.TrimSpace(r.FormValue(prefix + "description"))
+				if description == "" {
+					continue
+				}
+				points, _ := strconv.Atoi(r.FormValue(prefix + "points"))
+
+				var childID int64
+				if v := r.FormValue(prefix + "child_id"); v != "" {
+					childID, err = strconv.ParseInt(v, 10, 64)
+					if err != nil {
+						http.Error(w, "Invalid child ID", http.StatusBadRequest)
+						return
+					}
+					if !ownChildren[childID] {
+						http.Error(w, "Unauthorized", http.StatusUnauthorized)
+						return
+					}
+				}
+
+				batch = append(batch, models.NewChore{
+					Chore: &models.Chore{
+						UserID:      userID,
+						Description: description,
+						Points:      points,
+						IsRequired:  r.FormValue(prefix+"is_required") == "on",
+						Category:    strings.TrimSpace(r.FormValue(prefix + "category")),
+					},
+					ChildID: childID,
+				})
+			}
+
+			if len(batch) == 0 {
+				http.Error(w, "Select at least one chore", http.StatusBadRequest)
+				return
+			}
+
+			err = models.CreateChores(db, batch)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			for _, item := range batch {
+				hub.Publish(events.Event{
+					Type:        events.ChoreAdded,
+					UserID:      userID,
+					ChoreID:     item.Chore.ID,
+					Description: item.Chore.Description,
+					Points:      item.Chore.Points,
+				})
+			}
+
+			w.Header().Set("HX-Trigger", "refreshChoreList, refreshAssignments")
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<button class="action-button" hx-get="/add-chore" hx-target="#chore-action-container" hx-swap="innerHTML">Add Chore</button>`))
+		}
+	}
+}
+
+// function to save an existing chore as a reusable template
+func SaveChoreTemplateHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		paths := strings.Split(r.URL.Path, "/")
+		id, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid chore ID", http.StatusBadRequest)
+			return
+		}
+
+		chore, err := models.GetChoreByID(db, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Check if the userID matches the chore's userID and return Unauthorized if not
+		if userID != chore.UserID {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		t := &models.ChoreTemplate{
+			UserID:      userID,
+			Description: chore.Description,
+			Points:      chore.Points,
+			IsRequired:  chore.IsRequired,
+			Category:    chore.Category,
+		}
+		err = t.Save(db)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<button disabled>Saved</button>`))
+	}
+}
+
+func DeleteChoreTemplateHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		paths := strings.Split(r.URL.Path, "/")
+		id, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid template ID", http.StatusBadRequest)
+			return
+		}
+
+		t, err := models.GetChoreTemplateByID(db, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Check if the template belongs to the user
+		if userID != t.UserID {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		err = models.DeleteChoreTemplate(db, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"Adven-Chores/internal/events"
 	"Adven-Chores/internal/models"
+	"Adven-Chores/internal/notify"
+	"Adven-Chores/internal/photos"
 	"database/sql"
+	"fmt"
 	"html/template"
 	"log"
 	"net/http"
@@ -26,13 +30,15 @@ func ChoreListHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 			return
 		}
 
+		groups := models.GroupChoresByCategory(models.FilterChores(chores, choreFilterFromRequest(r)))
+
 		tmpl, err := template.ParseFiles("../../templates/chore_list.html")
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		err = tmpl.Execute(w, chores)
+		err = tmpl.Execute(w, groups)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -40,7 +46,23 @@ func ChoreListHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 	}
 }
 
-func AddChoreHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+// function to read the category and tag filter from the query string
+func choreFilterFromRequest(r *http.Request) models.ChoreFilter {
+	return models.ChoreFilter{
+		Category: strings.TrimSpace(r.URL.Query().Get("category")),
+		Tag:      strings.TrimSpace(r.URL.Query().Get("tag")),
+	}
+}
+
+// data shared by the add and edit chore forms
+type choreFormData struct {
+	Chore        *models.Chore
+	Categories   []string
+	Icons        []string
+	Difficulties []string
+}
+
+func AddChoreHandler(db *sql.DB, auth *goauth.AuthService, hub *events.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, err := ExtractUserID(r, auth)
 		if err != nil {
@@ -54,7 +76,12 @@ func AddChoreHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
-			err = tmpl.Execute(w, nil)
+			err = tmpl.Execute(w, choreFormData{
+				Chore:        &models.Chore{},
+				Categories:   models.DefaultChoreCategories,
+				Icons:        models.ChoreIcons,
+				Difficulties: models.DifficultyNames,
+			})
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -65,17 +92,31 @@ func AddChoreHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 			isRequired := r.FormValue("is_required") == "on"
 
 			chore := &models.Chore{
-				UserID:      userID,
-				Description: description,
-				Points:      points,
-				IsRequired:  isRequired,
+				UserID:        userID,
+				Description:   description,
+				Points:        points,
+				IsRequired:    isRequired,
+				Category:      strings.TrimSpace(r.FormValue("category")),
+				Tags:          models.ParseTags(r.FormValue("tags")),
+				Icon:          r.FormValue("icon"),
+				PartialPoints: r.FormValue("partial_points") == "on",
 			}
+			choreEffortFromForm(r, chore)
+
 			err := chore.Save(db)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
 
+			hub.Publish(events.Event{
+				Type:        events.ChoreAdded,
+				UserID:      userID,
+				ChoreID:     chore.ID,
+				Description: chore.Description,
+				Points:      chore.Points,
+			})
+
 			w.Header().Set("HX-Trigger", "refreshChoreList")
 			w.Header().Set("Content-Type", "text/html")
 			w.Write([]byte(`<button class="action-button" hx-get="/add-chore" hx-target="#chore-action-container" hx-swap="innerHTML">Add Chore</button>`))
@@ -119,7 +160,12 @@ func EditChoreHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 				return
 			}
 
-			err = tmpl.Execute(w, chore)
+			err = tmpl.Execute(w, choreFormData{
+				Chore:        chore,
+				Categories:   models.DefaultChoreCategories,
+				Icons:        models.ChoreIcons,
+				Difficulties: models.DifficultyNames,
+			})
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -128,6 +174,11 @@ func EditChoreHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 			chore.Description = r.FormValue("description")
 			chore.Points, _ = strconv.Atoi(r.FormValue("points"))
 			chore.IsRequired = r.FormValue("is_required") == "on"
+			chore.Category = strings.TrimSpace(r.FormValue("category"))
+			chore.Tags = models.ParseTags(r.FormValue("tags"))
+			chore.Icon = r.FormValue("icon")
+			chore.PartialPoints = r.FormValue("partial_points") == "on"
+			choreEffortFromForm(r, chore)
 
 			err := chore.Save(db)
 			if err != nil {
@@ -238,9 +289,9 @@ func AssignChoreHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 			return
 		}
 
-		err = models.AssignChoreToChild(db, childID, choreID)
+		err = models.ScheduleChore(db, childID, choreID, r.FormValue("due_date"), r.FormValue("recurrence"))
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
@@ -291,12 +342,20 @@ func AssignChoreFormHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFu
 			}
 		}
 
+		filter := choreFilterFromRequest(r)
+
 		data := struct {
-			Children []*models.Child
-			Chores   []*models.Chore
+			Children    []*models.Child
+			ChoreGroups []*models.ChoreGroup
+			Categories  []string
+			Tags        []string
+			Filter      models.ChoreFilter
 		}{
-			Children: children,
-			Chores:   availableChores,
+			Children:    children,
+			ChoreGroups: models.GroupChoresByCategory(models.FilterChores(availableChores, filter)),
+			Categories:  models.ChoreCategories(availableChores),
+			Tags:        models.ChoreTags(availableChores),
+			Filter:      filter,
 		}
 
 		tmpl, err := template.ParseFiles("../../templates/assign_chore.html")
@@ -348,7 +407,7 @@ func AssignmentActionHandler(db *sql.DB) http.HandlerFunc {
 	}
 }
 
-func DeleteAssignmentHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+func DeleteAssignmentHandler(db *sql.DB, auth *goauth.AuthService, store *photos.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodDelete {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -385,10 +444,15 @@ func DeleteAssignmentHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerF
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+
+		err = store.Remove(assignmentID)
+		if err != nil {
+			log.Printf("DeleteAssignmentHandler: failed to remove photo for assignment %d: %v", assignmentID, err)
+		}
 	}
 }
 
-func CompleteAssignmentHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+func CompleteAssignmentHandler(db *sql.DB, auth *goauth.AuthService, hub *events.Hub, notifier *notify.Dispatcher, store *photos.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -420,19 +484,74 @@ func CompleteAssignmentHandler(db *sql.DB, auth *goauth.AuthService) http.Handle
 			return
 		}
 
+		// A second submission mustn't replace the photo already handed in
+		if assignment.IsCompleted {
+			http.Error(w, "assignment is already completed", http.StatusBadRequest)
+			return
+		}
+
+		// Attach the photo proof, if one was uploaded, before marking complete
+		// so a rejected photo leaves the chore open
+		status, err := savePhotoUpload(w, r, store, userID, assignmentID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
 		err = models.CompleteAssignment(db, assignmentID)
 		if err != nil {
+			// Don't keep a photo for a chore that wasn't completed
+			if removeErr := store.Remove(assignmentID); removeErr != nil {
+				log.Printf("CompleteAssignmentHandler: failed to remove photo for assignment %d: %v", assignmentID, removeErr)
+			}
+
+			if err == models.ErrStepsIncomplete {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
+		// Let the parent know the chore is ready for review
+		child, err := models.GetChildByID(db, assignment.ChildID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		chore, err := models.GetChoreByID(db, assignment.Chore.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		notifier.Dispatch(&models.Notification{
+			UserID:  userID,
+			ChildID: child.ID,
+			Kind:    events.AssignmentCompleted,
+			Title:   "Chore completed",
+			Body:    fmt.Sprintf("%s completed \"%s\" and is waiting for %d points", child.Name, chore.Description, chore.Points),
+		})
+
+		hub.Publish(events.Event{
+			Type:         events.AssignmentCompleted,
+			UserID:       userID,
+			ChildID:      child.ID,
+			ChoreID:      chore.ID,
+			AssignmentID: assignment.ID,
+			ChildName:    child.Name,
+			Description:  chore.Description,
+			Points:       chore.Points,
+		})
+
 		// Refresh the child-dashboard to update assignment status
 		w.Header().Set("HX-Trigger", "refreshChildDashboard")
 		w.Header().Set("Content-Type", "text/html")
 	}
 }
 
-func RewardAssignmentHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+func RewardAssignmentHandler(db *sql.DB, auth *goauth.AuthService, hub *events.Hub, notifier *notify.Dispatcher, store *photos.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -464,12 +583,68 @@ func RewardAssignmentHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerF
 			return
 		}
 
-		err = models.RewardAssignment(db, assignmentID)
+		chore, err := models.GetChoreByID(db, assignment.Chore.ID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
+		reward, err := models.RewardAssignment(db, assignmentID, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		child := reward.Child
+
+		// The photo has served its purpose once the parent has approved the chore
+		err = store.Remove(assignmentID)
+		if err != nil {
+			log.Printf("RewardAssignmentHandler: failed to remove photo for assignment %d: %v", assignmentID, err)
+		}
+
+		hub.Publish(events.Event{
+			Type:         events.AssignmentRewarded,
+			UserID:       userID,
+			ChildID:      child.ID,
+			ChoreID:      chore.ID,
+			AssignmentID: assignment.ID,
+			ChildName:    child.Name,
+			Description:  chore.Description,
+			Points:       reward.Points,
+		})
+
+		for _, run := range reward.Quests {
+			notifier.Dispatch(&models.Notification{
+				UserID:  userID,
+				ChildID: child.ID,
+				Kind:    events.QuestCompleted,
+				Title:   "Quest complete",
+				Body:    fmt.Sprintf("%s completed the quest \"%s\"", child.Name, run.Quest.Name),
+			})
+
+			hub.Publish(events.Event{
+				Type:        events.QuestCompleted,
+				UserID:      userID,
+				ChildID:     child.ID,
+				RewardID:    run.Quest.BonusRewardID,
+				ChildName:   child.Name,
+				Description: run.Quest.Name,
+				Points:      run.Quest.BonusPoints,
+			})
+		}
+
+		if reward.LevelUp {
+			hub.Publish(events.Event{
+				Type:      events.ChildLevelUp,
+				UserID:    userID,
+				ChildID:   child.ID,
+				ChildName: child.Name,
+				Level:     child.Level(),
+			})
+		}
+
+		checkSavingsGoal(db, notifier, child)
+
 		// Refresh the children list to update points
 		w.Header().Set("HX-Trigger", "refreshList")
 		w.Header().Set("Content-Type", "text/html")
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/digest"
+	"Adven-Chores/internal/models"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/slate20/goauth"
+)
+
+// function to show the weekly digest as a printable page; defaults to the
+// last full week
+func DigestHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		week, err := time.ParseInLocation(models.DueDateLayout, r.URL.Query().Get("week"), time.Local)
+		if err != nil {
+			week = models.DigestWeek(time.Now())
+		}
+
+		d, err := models.GetDigest(db, userID, week)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		err = digest.Render(w, d, false)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
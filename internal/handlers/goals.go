@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/events"
+	"Adven-Chores/internal/models"
+	"Adven-Chores/internal/notify"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/slate20/goauth"
+)
+
+// function to notify the parent the first time a child's savings goal
+// becomes affordable. The flag is cleared again once the child drops below
+// the price so the next time they get there is reported too.
+func checkSavingsGoal(db *sql.DB, notifier *notify.Dispatcher, child *models.Child) {
+	goal, err := models.GetSavingsGoal(db, child.ID)
+	if err != nil {
+		log.Printf("checkSavingsGoal: %v", err)
+		return
+	}
+	if goal == nil {
+		return
+	}
+
+	affordable := goal.Affordable(child.Points)
+	if affordable == goal.Notified {
+		return
+	}
+
+	goal.Notified = affordable
+	err = goal.Save(db)
+	if err != nil {
+		log.Printf("checkSavingsGoal: %v", err)
+		return
+	}
+
+	if affordable {
+		notifier.Dispatch(&models.Notification{
+			UserID:  child.UserID,
+			ChildID: child.ID,
+			Kind:    events.GoalAffordable,
+			Title:   "Savings goal reached",
+			Body:    fmt.Sprintf("%s has saved enough points for \"%s\" (%d points)", child.Name, goal.Reward.Description, goal.Reward.PointCost),
+		})
+	}
+}
+
+// function to load a child from the last path segment and check it belongs to the user
+func pathChild(db *sql.DB, r *http.Request, userID int) (*models.Child, int, error) {
+	paths := strings.Split(r.URL.Path, "/")
+	childID, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("Invalid child ID")
+	}
+
+	child, err := models.GetChildByID(db, childID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if userID != child.UserID {
+		return nil, http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	return child, http.StatusOK, nil
+}
+
+func SetSavingsGoalHandler(db *sql.DB, auth *goauth.AuthService, notifier *notify.Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		rewardID, err := strconv.ParseInt(r.FormValue("reward_id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid reward ID", http.StatusBadRequest)
+			return
+		}
+
+		reward, err := models.GetRewardByID(db, rewardID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if userID != reward.UserID {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if !reward.VisibleTo(child) {
+			http.Error(w, "This reward isn't available to "+child.Name, http.StatusForbidden)
+			return
+		}
+
+		// Keep the locked points when switching goals, capped to the new price
+		locked := 0
+		current, err := models.GetSavingsGoal(db, child.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if current != nil {
+			locked = min(current.LockedPoints, reward.PriceFor(child))
+		}
+
+		goal := &models.SavingsGoal{
+			ChildID:      child.ID,
+			RewardID:     reward.ID,
+			LockedPoints: locked,
+		}
+
+		err = goal.Save(db)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		checkSavingsGoal(db, notifier, child)
+
+		http.Redirect(w, r, fmt.Sprintf("/rewards-store/%d", child.ID), http.StatusSeeOther)
+	}
+}
+
+func LockGoalPointsHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		goal, err := models.GetSavingsGoal(db, child.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if goal == nil {
+			http.Error(w, "No savings goal set", http.StatusBadRequest)
+			return
+		}
+
+		locked, err := strconv.Atoi(r.FormValue("locked_points"))
+		if err != nil || locked < 0 {
+			http.Error(w, "Invalid number of points", http.StatusBadRequest)
+			return
+		}
+
+		// There's no point locking more than the goal costs
+		goal.LockedPoints = min(locked, goal.Reward.PointCost)
+
+		err = goal.Save(db)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "refreshChildDashboard")
+		w.Header().Set("Content-Type", "text/html")
+	}
+}
+
+func ClearSavingsGoalHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		err = models.DeleteSavingsGoal(db, child.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "refreshChildDashboard")
+		w.Header().Set("Content-Type", "text/html")
+	}
+}
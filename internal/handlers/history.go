@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/models"
+	"database/sql"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/slate20/goauth"
+)
+
+// number of approved assignments shown on each page of a history
+const historyPageSize = 20
+
+// function to get the requested history page, defaulting to the first
+func requestedPage(r *http.Request) int {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		return 1
+	}
+	return page
+}
+
+// function to render a page of assignment history. url is where the newer
+// and older page buttons load from; when the history is opened in an action
+// container, closeURL is where its Close button loads from.
+func renderHistory(w http.ResponseWriter, history *models.AssignmentHistory, title, url string, showChild bool, closeURL, container string) {
+	data := struct {
+		History   *models.AssignmentHistory
+		Title     string
+		URL       string
+		ShowChild bool
+		CloseURL  string
+		Container string
+	}{
+		History:   history,
+		Title:     title,
+		URL:       url,
+		ShowChild: showChild,
+		CloseURL:  closeURL,
+		Container: container,
+	}
+
+	tmpl, err := template.ParseFiles("../../templates/assignment_history.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// function to show the chores a child has had approved
+func ChildHistoryHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		history, err := models.GetChildHistory(db, child.ID, requestedPage(r), historyPageSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// The parent panel opens the history in the child action container,
+		// asking for a Close button
+		closeURL := ""
+		if r.URL.Query().Get("close") != "" {
+			closeURL = "/child-action"
+		}
+
+		renderHistory(w, history, child.Name+"'s Chore History", fmt.Sprintf("/child-history/%d", child.ID), false, closeURL, "child-action-container")
+	}
+}
+
+// function to show who has done a chore and when
+func ChoreHistoryHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		paths := strings.Split(r.URL.Path, "/")
+		choreID, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid chore ID", http.StatusBadRequest)
+			return
+		}
+
+		// Only the household's own assignments are returned, so the history
+		// stays available after the chore is deleted
+		history, err := models.GetChoreHistory(db, userID, choreID, requestedPage(r), historyPageSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		title := "Chore History"
+		if len(history.Assignments) > 0 {
+			title = "History of " + history.Assignments[0].Chore.Description
+		}
+
+		renderHistory(w, history, title, fmt.Sprintf("/chore-history/%d", choreID), true, "/chore-action", "chore-action-container")
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/models"
+	"database/sql"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slate20/goauth"
+)
+
+// number of jar transfers shown on the child dashboard
+const jarTransferListSize = 10
+
+// function to render the jar settings summary and its settings button
+func renderJarSummary(w http.ResponseWriter, settings *models.JarSettings) {
+	tmpl, err := template.ParseFiles("../../templates/jar_summary.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, settings)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func JarSettingsHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		settings, err := models.GetJarSettings(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			data := struct {
+				Settings *models.JarSettings
+				Periods  []string
+			}{
+				Settings: settings,
+				Periods:  models.InterestPeriods,
+			}
+
+			tmpl, err := template.ParseFiles("../../templates/jar_settings.html")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			err = tmpl.Execute(w, data)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			settings.Enabled = r.FormValue("enabled") == "on"
+			settings.SpendPercent, _ = strconv.Atoi(r.FormValue("spend_percent"))
+			settings.SavePercent, _ = strconv.Atoi(r.FormValue("save_percent"))
+			settings.GivePercent, _ = strconv.Atoi(r.FormValue("give_percent"))
+			settings.InterestRate, _ = strconv.Atoi(r.FormValue("interest_rate"))
+			settings.InterestPeriod = r.FormValue("interest_period")
+
+			err = settings.Save(db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("HX-Trigger", "refreshChildDashboard")
+			renderJarSummary(w, settings)
+		}
+	}
+}
+
+func JarActionHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		settings, err := models.GetJarSettings(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		renderJarSummary(w, settings)
+	}
+}
+
+// function to move points between a child's jars
+func TransferJarsHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		settings, err := models.GetJarSettings(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !settings.Enabled {
+			http.Error(w, "Jars are turned off", http.StatusBadRequest)
+			return
+		}
+
+		amount, _ := strconv.Atoi(r.FormValue("amount"))
+		err = models.TransferJars(db, child, r.FormValue("from"), r.FormValue("to"), amount, strings.TrimSpace(r.FormValue("note")))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "refreshChildDashboard")
+		w.Header().Set("Content-Type", "text/html")
+	}
+}
+
+// function to give away points from a child's give jar
+func GiveFromJarHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		settings, err := models.GetJarSettings(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !settings.Enabled {
+			http.Error(w, "Jars are turned off", http.StatusBadRequest)
+			return
+		}
+
+		amount, _ := strconv.Atoi(r.FormValue("amount"))
+		err = models.GiveFromJar(db, child, amount, strings.TrimSpace(r.FormValue("note")))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "refreshChildDashboard, refreshList")
+		w.Header().Set("Content-Type", "text/html")
+	}
+}
+
+// function to pay any savings interest that is due; main runs it periodically
+func PayJarInterest(db *sql.DB, now time.Time) {
+	err := models.PayInterest(db, now)
+	if err != nil {
+		log.Printf("PayJarInterest: %v", err)
+	}
+}
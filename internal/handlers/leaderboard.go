@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/events"
+	"Adven-Chores/internal/models"
+	"Adven-Chores/internal/notify"
+	"database/sql"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slate20/goauth"
+)
+
+// periods the leaderboard can cover
+var leaderboardPeriods = []string{"week", "month"}
+
+const competitionButton = `<button class="action-button" hx-get="/add-competition" hx-target="#competition-action-container" hx-swap="innerHTML">New Competition</button>`
+
+// function to rank the household's children for this week or month
+func LeaderboardHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		period := r.URL.Query().Get("period")
+		if !slices.Contains(leaderboardPeriods, period) {
+			period = "week"
+		}
+		metric := r.URL.Query().Get("metric")
+		if !slices.Contains(models.Metrics, metric) {
+			metric = models.MetricPoints
+		}
+
+		now := time.Now()
+		tomorrow := models.PeriodStart("day", now).AddDate(0, 0, 1)
+		standings, err := models.GetStandings(db, userID, models.PeriodStart(period, now), tomorrow, metric)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		data := struct {
+			Period    string
+			Periods   []string
+			Metric    string
+			Metrics   []string
+			Labels    map[string]string
+			Standings []*models.Standing
+		}{
+			Period:    period,
+			Periods:   leaderboardPeriods,
+			Metric:    metric,
+			Metrics:   models.Metrics,
+			Labels:    models.MetricLabels,
+			Standings: standings,
+		}
+
+		tmpl, err := template.ParseFiles("../../templates/leaderboard.html")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = tmpl.Execute(w, data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// a competition with its current or final standings
+type competitionView struct {
+	*models.Competition
+	Running   bool
+	Standings []*models.Standing
+}
+
+func CompetitionListHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		competitions, err := models.GetCompetitionsByUserID(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		now := time.Now()
+		var views []*competitionView
+		for _, c := range competitions {
+			view := &competitionView{Competition: c, Running: c.Running(now)}
+			if view.Running {
+				view.Standings, err = c.Standings(db)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+			}
+			views = append(views, view)
+		}
+
+		tmpl, err := template.ParseFiles("../../templates/competition_list.html")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = tmpl.Execute(w, views)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func AddCompetitionHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			rewards, err := models.GetRewardsByUserID(db, userID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			now := time.Now()
+			data := struct {
+				Metrics   []string
+				Labels    map[string]string
+				Rewards   []*models.Reward
+				StartDate string
+				EndDate   string
+			}{
+				Metrics:   models.Metrics,
+				Labels:    models.MetricLabels,
+				Rewards:   rewards,
+				StartDate: now.Format(models.DueDateLayout),
+				EndDate:   now.AddDate(0, 0, 6).Format(models.DueDateLayout),
+			}
+
+			tmpl, err := template.ParseFiles("../../templates/add_competition.html")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			err = tmpl.Execute(w, data)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			competition := &models.Competition{
+				UserID:    userID,
+				Name:      strings.TrimSpace(r.FormValue("name")),
+				Metric:    r.FormValue("metric"),
+				StartDate: r.FormValue("start_date"),
+				EndDate:   r.FormValue("end_date"),
+			}
+
+			if prizeID, err := strconv.ParseInt(r.FormValue("prize_reward_id"), 10, 64); err == nil && prizeID != 0 {
+				prize, err := models.GetRewardByID(db, prizeID)
+				if err != nil || prize.UserID != userID {
+					http.Error(w, "Invalid prize", http.StatusBadRequest)
+					return
+				}
+				competition.PrizeRewardID = prize.ID
+			}
+
+			err = competition.Save(db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			// Trigger refresh and return the New Competition button
+			w.Header().Set("HX-Trigger", "refreshCompetitions")
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(competitionButton))
+		}
+	}
+}
+
+func DeleteCompetitionHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		paths := strings.Split(r.URL.Path, "/")
+		id, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid competition ID", http.StatusBadRequest)
+			return
+		}
+
+		competition, err := models.GetCompetitionByID(db, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if userID != competition.UserID {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		err = models.DeleteCompetition(db, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func CompetitionActionHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(competitionButton))
+	}
+}
+
+// function to close the competitions that have ended, granting their prizes
+// and announcing the winners; main runs it periodically
+func CloseCompetitions(db *sql.DB, hub *events.Hub, notifier *notify.Dispatcher, now time.Time) {
+	closed, err := models.CloseCompetitions(db, now)
+	if err != nil {
+		log.Printf("CloseCompetitions: %v", err)
+	}
+	for _, c := range closed {
+		announceCompetition(hub, notifier, c)
+	}
+}
+
+// function to tell the parent who won a competition that just closed
+func announceCompetition(hub *events.Hub, notifier *notify.Dispatcher, c *models.Competition) {
+	body := fmt.Sprintf("Nobody completed any chores during \"%s\"", c.Name)
+	if c.Winners != "" {
+		body = fmt.Sprintf("%s won \"%s\"", c.Winners, c.Name)
+		if c.PrizeName != "" {
+			body += " and received " + c.PrizeName
+		}
+	}
+
+	notifier.Dispatch(&models.Notification{
+		UserID: c.UserID,
+		Kind:   events.CompetitionClosed,
+		Title:  "Competition finished",
+		Body:   body,
+	})
+
+	hub.Publish(events.Event{
+		Type:        events.CompetitionClosed,
+		UserID:      c.UserID,
+		RewardID:    c.PrizeRewardID,
+		ChildName:   c.Winners,
+		Description: c.Name,
+	})
+}
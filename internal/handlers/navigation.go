@@ -23,13 +23,19 @@ func ChildNavHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 			return
 		}
 
-		tmpl, err := template.ParseFiles("../../templates/child_nav.html")
+		avatars, err := models.GetAvatars(db, children)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		err = tmpl.Execute(w, children)
+		tmpl, err := template.ParseFiles("../../templates/child_nav.html", "../../templates/avatar.html")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = tmpl.Execute(w, avatars)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/models"
+	"database/sql"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/slate20/goauth"
+)
+
+// number of notifications shown in the parent panel inbox
+const inboxSize = 20
+
+func NotificationListHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		notifications, err := models.GetNotificationsByUserID(db, userID, inboxSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		tmpl, err := template.ParseFiles("../../templates/notification_list.html")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = tmpl.Execute(w, notifications)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func ReadNotificationHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		paths := strings.Split(r.URL.Path, "/")
+		id, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid notification ID", http.StatusBadRequest)
+			return
+		}
+
+		notification, err := models.GetNotificationByID(db, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// check if userID matches notification.UserID and return Unauthorized if not
+		if userID != notification.UserID {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		notification.IsRead = true
+		err = notification.Save(db)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "refreshNotifications")
+		w.Header().Set("Content-Type", "text/html")
+	}
+}
+
+func ReadAllNotificationsHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		err = models.MarkAllNotificationsRead(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "refreshNotifications")
+		w.Header().Set("Content-Type", "text/html")
+	}
+}
+
+// function to show and save the parent's notification preferences
+func NotificationPreferencesHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		prefs, err := models.GetNotificationPreferences(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			tmpl, err := template.ParseFiles("../../templates/notification_preferences.html")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			err = tmpl.Execute(w, prefs)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			prefs.NotifyCompleted = r.FormValue("notify_completed") == "on"
+			prefs.NotifyRedeemed = r.FormValue("notify_redeemed") == "on"
+			prefs.NotifyDigest = r.FormValue("notify_digest") == "on"
+			prefs.InboxEnabled = r.FormValue("inbox_enabled") == "on"
+			prefs.EmailEnabled = r.FormValue("email_enabled") == "on"
+			prefs.Email = strings.TrimSpace(r.FormValue("email"))
+			prefs.WebhookEnabled = r.FormValue("webhook_enabled") == "on"
+			prefs.WebhookURL = strings.TrimSpace(r.FormValue("webhook_url"))
+
+			if prefs.WebhookEnabled && !strings.HasPrefix(prefs.WebhookURL, "http://") && !strings.HasPrefix(prefs.WebhookURL, "https://") {
+				http.Error(w, "Webhook URL must start with http:// or https://", http.StatusBadRequest)
+				return
+			}
+
+			err = prefs.Save(db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<button class="action-button" hx-get="/notification-preferences" hx-target="#notification-action-container" hx-swap="innerHTML">Notification Settings</button>`))
+		}
+	}
+}
+
+func NotificationActionHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<button class="action-button" hx-get="/notification-preferences" hx-target="#notification-action-container" hx-swap="innerHTML">Notification Settings</button>`))
+	}
+}
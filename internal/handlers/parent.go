@@ -58,16 +58,68 @@ func ParentPanelHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 			return
 		}
 
+		notifications, err := models.GetNotificationsByUserID(db, userID, inboxSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		webhooks, err := models.GetWebhooksByUserID(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		deliveries, err := models.GetWebhookDeliveriesByUserID(db, userID, deliveryLogSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		allowance, err := loadAllowanceList(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		jars, err := models.GetJarSettings(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		adjustments, err := loadAdjustmentReport(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
 		data := struct {
-			Children    []*models.Child
-			Chores      []*models.Chore
-			Assignments []*models.Assignment
-			Rewards     []*models.Reward
+			Children          []*models.Child
+			Chores            []*models.ChoreGroup
+			ChoreCategories   []string
+			ChoreTags         []string
+			Assignments       []*models.Assignment
+			Rewards           []*models.Reward
+			Notifications     []*models.Notification
+			Webhooks          []*models.Webhook
+			WebhookDeliveries []*models.WebhookDelivery
+			Allowance         *allowanceListData
+			Jars              *models.JarSettings
+			Adjustments       *adjustmentReport
 		}{
-			Children:    children,
-			Chores:      chores,
-			Assignments: assignments,
-			Rewards:     rewards,
+			Children:          children,
+			Chores:            models.GroupChoresByCategory(chores),
+			ChoreCategories:   models.ChoreCategories(chores),
+			ChoreTags:         models.ChoreTags(chores),
+			Assignments:       assignments,
+			Rewards:           rewards,
+			Notifications:     notifications,
+			Webhooks:          webhooks,
+			WebhookDeliveries: deliveries,
+			Allowance:         allowance,
+			Jars:              jars,
+			Adjustments:       adjustments,
 		}
 
 		tmpl, err := template.ParseFiles(
@@ -76,6 +128,12 @@ func ParentPanelHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 			"../../templates/chore_list.html",
 			"../../templates/assignments_list.html",
 			"../../templates/reward_list.html",
+			"../../templates/notification_list.html",
+			"../../templates/webhook_list.html",
+			"../../templates/webhook_deliveries.html",
+			"../../templates/allowance_list.html",
+			"../../templates/jar_summary.html",
+			"../../templates/adjustment_list.html",
 		)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/models"
+	"Adven-Chores/internal/photos"
+	"database/sql"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/slate20/goauth"
+)
+
+// function to store the optional photo sent with a completion request.
+// Returns the HTTP status to use when the upload is rejected.
+func savePhotoUpload(w http.ResponseWriter, r *http.Request, store *photos.Store, userID int, assignmentID int64) (int, error) {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return http.StatusOK, nil
+	}
+
+	// Leave some room for the multipart framing around the image itself
+	r.Body = http.MaxBytesReader(w, r.Body, photos.MaxUploadSize+1<<20)
+	err := r.ParseMultipartForm(photos.MaxUploadSize)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return http.StatusRequestEntityTooLarge, photos.ErrTooLarge
+		}
+		return http.StatusBadRequest, err
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("photo")
+	if err == http.ErrMissingFile {
+		return http.StatusOK, nil
+	}
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	defer file.Close()
+
+	_, err = store.Save(userID, assignmentID, file)
+	switch err {
+	case nil:
+		return http.StatusOK, nil
+	case photos.ErrTooLarge:
+		return http.StatusRequestEntityTooLarge, err
+	case photos.ErrUnsupported:
+		return http.StatusUnsupportedMediaType, err
+	default:
+		return http.StatusInternalServerError, err
+	}
+}
+
+func AssignmentPhotoHandler(db *sql.DB, auth *goauth.AuthService, store *photos.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		paths := strings.Split(r.URL.Path, "/")
+		assignmentID, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid assignment ID", http.StatusBadRequest)
+			return
+		}
+
+		photo, err := models.GetAssignmentPhoto(db, assignmentID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		// check if userID matches photo.UserID and return Unauthorized if not
+		if userID != photo.UserID {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		path, contentType := store.Open(photo, r.URL.Query().Get("size") == "thumb")
+		file, err := os.Open(path)
+		if err != nil {
+			http.Error(w, "Photo not found", http.StatusNotFound)
+			return
+		}
+		defer file.Close()
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "private, max-age=3600")
+		http.ServeContent(w, r, "", photo.CreatedAt, file)
+	}
+}
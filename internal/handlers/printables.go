@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/models"
+	"Adven-Chores/internal/printable"
+	"database/sql"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/slate20/goauth"
+)
+
+// number of recent redemptions printed as coupons; one page of coupons
+const couponCount = 8
+
+// function to check whether a printable was asked for as a PDF
+func wantsPDF(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "pdf"
+}
+
+// function to set the headers for a PDF shown in the browser
+func pdfHeaders(w http.ResponseWriter, filename string) {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+}
+
+// function to render a printable as a print-optimized HTML page
+func renderPrintable(w http.ResponseWriter, name string, data interface{}) {
+	tmpl, err := template.ParseFiles("../../templates/" + name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// function to print a child's chore chart for a week, with a box to tick
+// for each chore on each day it's due
+func ChoreChartHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		assignments, err := models.GetAssignmentsByChild(db, child.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		chart := printable.BuildChart(child, assignments, requestedWeek(r), time.Now())
+		paper := printable.GetPaper(r.URL.Query().Get("paper"))
+
+		if wantsPDF(r) {
+			pdfHeaders(w, fmt.Sprintf("chore-chart-%s.pdf", chart.Week.Start.Format(models.DueDateLayout)))
+			err = printable.WriteChartPDF(w, chart, paper)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		renderPrintable(w, "chore_chart_print.html", struct {
+			Chart  *printable.Chart
+			Paper  *printable.Paper
+			Papers []*printable.Paper
+			URL    string
+		}{
+			Chart:  chart,
+			Paper:  paper,
+			Papers: printable.Papers,
+			URL:    fmt.Sprintf("/chore-chart/%d", child.ID),
+		})
+	}
+}
+
+// function to print coupons for a child's most recently redeemed rewards
+func CouponsHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		redemptions, err := models.GetRedemptionsByChild(db, child.ID, couponCount)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var coupons []*printable.Coupon
+		for _, rr := range redemptions {
+			coupons = append(coupons, &printable.Coupon{Child: child, Redemption: rr})
+		}
+		paper := printable.GetPaper(r.URL.Query().Get("paper"))
+
+		if wantsPDF(r) {
+			pdfHeaders(w, fmt.Sprintf("coupons-%d.pdf", child.ID))
+			err = printable.WriteCouponsPDF(w, coupons, paper)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		renderPrintable(w, "coupons_print.html", struct {
+			Child   *models.Child
+			Coupons []*printable.Coupon
+			Paper   *printable.Paper
+			Papers  []*printable.Paper
+			URL     string
+		}{
+			Child:   child,
+			Coupons: coupons,
+			Paper:   paper,
+			Papers:  printable.Papers,
+			URL:     fmt.Sprintf("/coupons/%d", child.ID),
+		})
+	}
+}
@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/models"
+	"database/sql"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slate20/goauth"
+)
+
+const questButton = `<button class="action-button" hx-get="/add-quest" hx-target="#quest-action-container" hx-swap="innerHTML">New Quest</button>`
+
+// function to read a quest definition from the add/edit quest form
+func questFromForm(db *sql.DB, r *http.Request, userID int, quest *models.Quest) error {
+	quest.Name = strings.TrimSpace(r.FormValue("name"))
+	quest.Story = strings.TrimSpace(r.FormValue("story"))
+	quest.Deadline = r.FormValue("deadline")
+
+	quest.BonusPoints, _ = strconv.Atoi(r.FormValue("bonus_points"))
+
+	quest.BonusRewardID = 0
+	if rewardID, err := strconv.ParseInt(r.FormValue("bonus_reward_id"), 10, 64); err == nil && rewardID != 0 {
+		reward, err := models.GetRewardByID(db, rewardID)
+		if err != nil || reward.UserID != userID {
+			return fmt.Errorf("Invalid bonus reward")
+		}
+		quest.BonusRewardID = reward.ID
+	}
+
+	quest.Chores = nil
+	for _, value := range r.Form["chore_ids"] {
+		choreID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("Invalid chore ID")
+		}
+		chore, err := models.GetChoreByID(db, choreID)
+		if err != nil || chore.UserID != userID {
+			return fmt.Errorf("Invalid chore ID")
+		}
+		quest.Chores = append(quest.Chores, chore)
+	}
+
+	return nil
+}
+
+// function to render the add or edit quest form
+func renderQuestForm(w http.ResponseWriter, db *sql.DB, userID int, name string, quest *models.Quest) {
+	chores, err := models.GetChoresByUserID(db, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rewards, err := models.GetRewardsByUserID(db, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Quest   *models.Quest
+		Chores  []*models.Chore
+		Rewards []*models.Reward
+	}{
+		Quest:   quest,
+		Chores:  chores,
+		Rewards: rewards,
+	}
+
+	tmpl, err := template.ParseFiles("../../templates/" + name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// function to get a quest from the last path segment, checking it belongs to the user
+func pathQuest(db *sql.DB, r *http.Request, userID int) (*models.Quest, int, error) {
+	paths := strings.Split(r.URL.Path, "/")
+	id, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("Invalid quest ID")
+	}
+
+	quest, err := models.GetQuestByID(db, id)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if userID != quest.UserID {
+		return nil, http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	return quest, http.StatusOK, nil
+}
+
+func QuestListHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		quests, err := models.GetQuestsByUserID(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		runs, err := models.GetQuestRunsByUserID(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		children, err := models.GetChildrenByUserID(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		data := struct {
+			Quests   []*models.Quest
+			Runs     []*models.QuestRun
+			Children []*models.Child
+		}{
+			Quests:   quests,
+			Runs:     runs,
+			Children: children,
+		}
+
+		tmpl, err := template.ParseFiles("../../templates/quest_list.html")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = tmpl.Execute(w, data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func AddQuestHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			renderQuestForm(w, db, userID, "add_quest.html", &models.Quest{})
+		} else if r.Method == http.MethodPost {
+			quest := &models.Quest{UserID: userID}
+			err = questFromForm(db, r, userID, quest)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			err = quest.Save(db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			// Trigger refresh and return the New Quest button
+			w.Header().Set("HX-Trigger", "refreshQuests")
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(questButton))
+		}
+	}
+}
+
+func EditQuestHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		quest, status, err := pathQuest(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			renderQuestForm(w, db, userID, "edit_quest.html", quest)
+		} else if r.Method == http.MethodPost {
+			err = questFromForm(db, r, userID, quest)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			err = quest.Save(db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			// Trigger refresh and return the New Quest button
+			w.Header().Set("HX-Trigger", "refreshQuests")
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(questButton))
+		}
+	}
+}
+
+func DeleteQuestHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		quest, status, err := pathQuest(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		err = models.DeleteQuest(db, quest.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "refreshQuests")
+	}
+}
+
+// function to send a child on a quest, assigning its chores
+func StartQuestHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		quest, status, err := pathQuest(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		childID, err := strconv.ParseInt(r.FormValue("child_id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid child ID", http.StatusBadRequest)
+			return
+		}
+
+		child, err := models.GetChildByID(db, childID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if userID != child.UserID {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		_, err = models.StartQuest(db, quest, child, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "refreshQuests, refreshAssignments")
+	}
+}
+
+// function to stop a child's quest; the chores it assigned stay assigned
+func AbandonQuestHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		paths := strings.Split(r.URL.Path, "/")
+		id, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid quest run ID", http.StatusBadRequest)
+			return
+		}
+
+		run, err := models.GetQuestRunByID(db, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if userID != run.UserID {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		err = models.DeleteQuestRun(db, run.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "refreshQuests")
+	}
+}
+
+func QuestActionHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(questButton))
+	}
+}
+
+// function to show a child's quests and their progress on the dashboard
+func ChildQuestsHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		runs, err := models.GetQuestRunsByChild(db, child.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		data := struct {
+			Child *models.Child
+			Runs  []*models.QuestRun
+		}{
+			Child: child,
+			Runs:  runs,
+		}
+
+		tmpl, err := template.ParseFiles("../../templates/child_quests.html")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = tmpl.Execute(w, data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"Adven-Chores/internal/events"
 	"Adven-Chores/internal/models"
+	"Adven-Chores/internal/notify"
 	"database/sql"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
@@ -10,6 +13,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/slate20/goauth"
 )
@@ -42,6 +46,87 @@ func RewardListHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 	}
 }
 
+// data for the add and edit reward forms
+type rewardFormData struct {
+	Reward   *models.Reward
+	Periods  []string
+	Weekdays []time.Weekday
+	Children []*models.Child
+}
+
+// function to render the add or edit reward form
+func renderRewardForm(w http.ResponseWriter, db *sql.DB, userID int, file string, reward *models.Reward) {
+	children, err := models.GetChildrenByUserID(db, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl, err := template.ParseFiles("../../templates/" + file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := rewardFormData{
+		Reward:   reward,
+		Periods:  models.LimitPeriods,
+		Weekdays: models.Weekdays,
+		Children: children,
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// function to read the stock, cooldown and availability fields of the reward forms
+func rewardLimitsFromForm(r *http.Request, reward *models.Reward) {
+	reward.Stock, _ = strconv.Atoi(r.FormValue("stock"))
+	reward.StockPeriod = r.FormValue("stock-period")
+	reward.CooldownHours, _ = strconv.Atoi(r.FormValue("cooldown-hours"))
+	reward.ChildLimit, _ = strconv.Atoi(r.FormValue("child-limit"))
+	reward.ChildLimitPeriod = r.FormValue("child-limit-period")
+	reward.AvailableFrom = r.FormValue("available-from")
+	reward.AvailableUntil = r.FormValue("available-until")
+	reward.RequiresChores = r.FormValue("requires-chores") == "on"
+
+	reward.AvailableDays = nil
+	for _, value := range r.Form["available-days"] {
+		day, err := strconv.Atoi(value)
+		if err == nil && day >= 0 && day <= 6 {
+			reward.AvailableDays = append(reward.AvailableDays, day)
+		}
+	}
+}
+
+// function to read which children and ages a reward is for and any per-child
+// prices. Only the user's own children are considered.
+func rewardTargetingFromForm(r *http.Request, reward *models.Reward, children []*models.Child) {
+	reward.MinAge, _ = strconv.Atoi(r.FormValue("min-age"))
+	reward.MaxAge, _ = strconv.Atoi(r.FormValue("max-age"))
+
+	targets := make(map[string]bool)
+	for _, value := range r.Form["target-children"] {
+		targets[value] = true
+	}
+
+	reward.ChildIDs = nil
+	reward.Prices = make(map[int64]int)
+	for _, child := range children {
+		id := strconv.FormatInt(child.ID, 10)
+		if targets[id] {
+			reward.ChildIDs = append(reward.ChildIDs, child.ID)
+		}
+		price, err := strconv.Atoi(r.FormValue("price-" + id))
+		if err == nil && price > 0 {
+			reward.Prices[child.ID] = price
+		}
+	}
+}
+
 func AddRewardHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, err := ExtractUserID(r, auth)
@@ -51,17 +136,7 @@ func AddRewardHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 		}
 
 		if r.Method == http.MethodGet {
-			tmpl, err := template.ParseFiles("../../templates/add_reward.html")
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-
-			err = tmpl.Execute(w, nil)
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
+			renderRewardForm(w, db, userID, "add_reward.html", &models.Reward{})
 		} else if r.Method == http.MethodPost {
 			description := r.FormValue("description")
 			pointCost, _ := strconv.Atoi(r.FormValue("point-cost"))
@@ -71,8 +146,16 @@ func AddRewardHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 				Description: description,
 				PointCost:   pointCost,
 			}
+			rewardLimitsFromForm(r, reward)
 
-			err := reward.Save(db)
+			children, err := models.GetChildrenByUserID(db, userID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			rewardTargetingFromForm(r, reward, children)
+
+			err = reward.Save(db)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -113,28 +196,20 @@ func EditRewardHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
 		}
 
 		if r.Method == http.MethodGet {
-			reward, err := models.GetRewardByID(db, id)
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
+			renderRewardForm(w, db, userID, "edit_reward.html", reward)
+		} else if r.Method == http.MethodPost {
+			reward.Description = r.FormValue("description")
+			reward.PointCost, _ = strconv.Atoi(r.FormValue("point-cost"))
+			rewardLimitsFromForm(r, reward)
 
-			tmpl, err := template.ParseFiles("../../templates/edit_reward.html")
+			children, err := models.GetChildrenByUserID(db, userID)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
+			rewardTargetingFromForm(r, reward, children)
 
-			err = tmpl.Execute(w, reward)
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-		} else if r.Method == http.MethodPost {
-			reward.Description = r.FormValue("description")
-			reward.PointCost, _ = strconv.Atoi(r.FormValue("point-cost"))
-
-			err := reward.Save(db)
+			err = reward.Save(db)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -217,23 +292,87 @@ func RewardsStoreHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc
 			return
 		}
 
-		rewards, err := models.GetRewardsByUserID(db, userID)
+		// check if userID matches child.UserID and return Unauthorized if not
+		if userID != child.UserID {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		allRewards, err := models.GetRewardsByUserID(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Only show the rewards meant for this child, at this child's price
+		rewards := models.RewardsForChild(allRewards, child)
+
+		goal, err := models.GetSavingsGoal(db, child.ID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
+		// Work out which rewards are out of stock, on cooldown or outside their window
+		now := time.Now()
+		unavailable := make(map[int64]string)
+		for _, reward := range rewards {
+			reason, err := reward.Unavailable(db, child.ID, now)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if reason != "" {
+				unavailable[reward.ID] = reason
+			}
+		}
+
+		// Open required chores can lock the whole store or selected rewards
+		rules, blocking, err := requiredChoreLock(db, child)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		storeLocked := false
+		for _, reward := range rewards {
+			if rules.Locks(reward) && len(blocking) > 0 {
+				storeLocked = true
+				if unavailable[reward.ID] == "" {
+					unavailable[reward.ID] = requiredChoresReason
+				}
+			}
+		}
+		if !storeLocked {
+			blocking = nil
+		}
+
 		// Sort rewards by point cost
 		sort.Slice(rewards, func(i, j int) bool {
 			return rewards[i].PointCost < rewards[j].PointCost
 		})
 
+		spendable, err := models.LimitToSpendJar(db, child, models.SpendablePoints(child, goal, 0))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
 		data := struct {
-			Child   *models.Child
-			Rewards []*models.Reward
+			Child        *models.Child
+			Rewards      []*models.Reward
+			Goal         *models.SavingsGoal
+			LockedPoints int
+			Spendable    int
+			Unavailable  map[int64]string
+			Blocking     []*models.Assignment
 		}{
-			Child:   child,
-			Rewards: rewards,
+			Child:        child,
+			Rewards:      rewards,
+			Goal:         goal,
+			LockedPoints: goal.Locked(child.Points),
+			Spendable:    spendable,
+			Unavailable:  unavailable,
+			Blocking:     blocking,
 		}
 
 		tmpl, err := template.ParseFiles("../../templates/rewards_store.html")
@@ -250,7 +389,7 @@ func RewardsStoreHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc
 	}
 }
 
-func RedeemRewardHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+func RedeemRewardHandler(db *sql.DB, auth *goauth.AuthService, hub *events.Hub, notifier *notify.Dispatcher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, err := ExtractUserID(r, auth)
 		if err != nil {
@@ -293,29 +432,147 @@ func RedeemRewardHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc
 			return
 		}
 
-		if child.Points < reward.PointCost {
-			log.Printf("Error: Not enough points")
-			http.Error(w, "Not enough points", http.StatusBadRequest)
+		// Re-check targeting server-side and charge this child's price
+		if !reward.VisibleTo(child) {
+			log.Printf("Error: reward %d is not available to child %d", reward.ID, child.ID)
+			http.Error(w, "This reward isn't available to "+child.Name, http.StatusForbidden)
 			return
 		}
+		price := reward.PriceFor(child)
 
-		// Deduct points and add reward to child
-		child.Points -= reward.PointCost
-		if child.Rewards == "" {
-			child.Rewards = reward.Description
-		} else {
-			child.Rewards += ", " + reward.Description
+		// Open required chores can lock the reward
+		rules, blocking, err := requiredChoreLock(db, child)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(blocking) > 0 && rules.Locks(reward) {
+			log.Printf("Error: reward %d is locked by required chores for child %d", reward.ID, child.ID)
+			http.Error(w, requiredChoresReason, http.StatusForbidden)
+			return
 		}
 
-		err = child.Save(db)
+		goal, err := models.GetSavingsGoal(db, child.ID)
 		if err != nil {
 			log.Printf("Error: %v", err)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
+		// Deduct points and add reward to child, re-checking the balance, stock and limits
+		err = models.RedeemReward(db, child, reward, price, time.Now())
+		if err == models.ErrNotEnoughPoints {
+			log.Printf("Error: Not enough points")
+			http.Error(w, "Not enough points", http.StatusBadRequest)
+			return
+		}
+		var unavailable *models.UnavailableError
+		if errors.As(err, &unavailable) {
+			log.Printf("Error: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("Error: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Buying the goal completes it; buying something else may put it out of reach again
+		if goal != nil && goal.RewardID == reward.ID {
+			err = models.DeleteSavingsGoal(db, child.ID)
+			if err != nil {
+				log.Printf("Error: %v", err)
+			}
+		} else if goal != nil {
+			checkSavingsGoal(db, notifier, child)
+		}
+
+		notifier.Dispatch(&models.Notification{
+			UserID:  userID,
+			ChildID: child.ID,
+			Kind:    events.RewardRedeemed,
+			Title:   "Reward redeemed",
+			Body:    fmt.Sprintf("%s spent %d points on \"%s\"", child.Name, price, reward.Description),
+		})
+
+		hub.Publish(events.Event{
+			Type:        events.RewardRedeemed,
+			UserID:      userID,
+			ChildID:     child.ID,
+			RewardID:    reward.ID,
+			ChildName:   child.Name,
+			Description: reward.Description,
+			Points:      price,
+		})
+
 		// Redirect back to rewards store page
 		log.Printf("Redirecting to /rewards-store/%d", childID)
 		http.Redirect(w, r, fmt.Sprintf("/rewards-store/%d", childID), http.StatusSeeOther)
 	}
 }
+
+// shown on rewards that are locked by open required chores
+const requiredChoresReason = "Finish your required chores first"
+
+// function to get the household store rules and the child's required chores
+// that are still waiting to be approved
+func requiredChoreLock(db *sql.DB, child *models.Child) (*models.StoreRules, []*models.Assignment, error) {
+	rules, err := models.GetStoreRules(db, child.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rules.RequiredChoresLock == models.StoreLockOff {
+		return rules, nil, nil
+	}
+
+	blocking, err := models.GetBlockingChores(db, child.ID, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	return rules, blocking, nil
+}
+
+func StoreRulesHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		rules, err := models.GetStoreRules(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			tmpl, err := template.ParseFiles("../../templates/store_rules.html")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			err = tmpl.Execute(w, rules)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			rules.RequiredChoresLock = r.FormValue("required-chores-lock")
+
+			err = rules.Save(db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			// Trigger refresh and return the Add Reward button
+			w.Header().Set("HX-Trigger", "refreshRewardList")
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<button class="action-button" hx-get="/add-reward" hx-target="#reward-action-container" hx-swap="innerHTML">Add Reward</button>`))
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/charts"
+	"Adven-Chores/internal/models"
+	"database/sql"
+	"html/template"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slate20/goauth"
+)
+
+// how many weeks the statistics page can cover
+var statsPeriods = []int{4, 12, 26}
+
+// number of chores shown in the most and least popular charts
+const popularChoreCount = 5
+
+// a child's charts on the statistics page
+type childCharts struct {
+	*models.ChildStats
+	Points template.HTML
+}
+
+// function to turn chore popularity into bars
+func popularityBars(chores []*models.ChorePopularity) []charts.Bar {
+	var bars []charts.Bar
+	for _, c := range chores {
+		bars = append(bars, charts.Bar{Label: strings.TrimSpace(c.Icon + " " + c.Description), Value: c.Count})
+	}
+	return bars
+}
+
+// function to turn a percentage into a bar; -1 means there was nothing to measure
+func rateBar(label string, rate int) charts.Bar {
+	if rate < 0 {
+		return charts.Bar{Label: label, Text: "n/a"}
+	}
+	return charts.Bar{Label: label, Value: rate, Text: strconv.Itoa(rate) + "%"}
+}
+
+// function to show the household's statistics as charts
+func StatsHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		weeks, _ := strconv.Atoi(r.URL.Query().Get("weeks"))
+		if !slices.Contains(statsPeriods, weeks) {
+			weeks = statsPeriods[0]
+		}
+
+		stats, err := models.GetStats(db, userID, weeks, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var children []*childCharts
+		var completion, onTime []charts.Bar
+		for _, c := range stats.Children {
+			var labels []string
+			earned := charts.Series{Name: "Earned"}
+			spent := charts.Series{Name: "Spent"}
+			for _, week := range c.Weeks {
+				labels = append(labels, week.Start.Format("Jan 2"))
+				earned.Values = append(earned.Values, week.Earned)
+				spent.Values = append(spent.Values, week.Spent)
+			}
+			children = append(children, &childCharts{ChildStats: c, Points: charts.Columns(labels, []charts.Series{earned, spent})})
+
+			completion = append(completion, rateBar(c.ChildName, c.CompletionRate()))
+			onTime = append(onTime, rateBar(c.ChildName, c.OnTimeRate()))
+		}
+
+		data := struct {
+			Weeks        int
+			Periods      []int
+			Stats        *models.Stats
+			Children     []*childCharts
+			Completion   template.HTML
+			OnTime       template.HTML
+			MostPopular  template.HTML
+			LeastPopular template.HTML
+		}{
+			Weeks:        weeks,
+			Periods:      statsPeriods,
+			Stats:        stats,
+			Children:     children,
+			Completion:   charts.Bars(completion, 100),
+			OnTime:       charts.Bars(onTime, 100),
+			MostPopular:  charts.Bars(popularityBars(stats.MostPopular(popularChoreCount)), 0),
+			LeastPopular: charts.Bars(popularityBars(stats.LeastPopular(popularChoreCount)), 0),
+		}
+
+		tmpl, err := template.ParseFiles("../../templates/stats.html")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = tmpl.Execute(w, data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
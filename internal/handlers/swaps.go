@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/events"
+	"Adven-Chores/internal/models"
+	"Adven-Chores/internal/notify"
+	"database/sql"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slate20/goauth"
+)
+
+// number of swaps shown in each swap history
+const swapListSize = 10
+
+// function to get the Propose a Swap button of a child's dashboard
+func swapButton(childID int64) string {
+	return fmt.Sprintf(`<button class="action-button" hx-get="/propose-swap/%d" hx-target="#swap-action-container" hx-swap="innerHTML">Propose a Swap</button>`, childID)
+}
+
+const swapRulesButton = `<button class="action-button" hx-get="/swap-rules" hx-target="#swap-action-container" hx-swap="innerHTML">Swap Rules</button>`
+
+// function to get a swap from the last path segment, checking it belongs to the user
+func pathSwap(db *sql.DB, r *http.Request, userID int) (*models.ChoreSwap, int, error) {
+	paths := strings.Split(r.URL.Path, "/")
+	id, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("Invalid swap ID")
+	}
+
+	swap, err := models.GetSwapByID(db, id)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if userID != swap.UserID {
+		return nil, http.StatusUnauthorized, fmt.Errorf("Unauthorized")
+	}
+
+	return swap, http.StatusOK, nil
+}
+
+// function to let the household know a swap went through
+func publishSwapCompleted(hub *events.Hub, swap *models.ChoreSwap) {
+	hub.Publish(events.Event{
+		Type:         events.SwapCompleted,
+		UserID:       swap.UserID,
+		ChildID:      swap.ToChildID,
+		AssignmentID: swap.OfferedAssignmentID,
+		ChildName:    swap.ToChildName,
+		Description:  fmt.Sprintf("%s swapped \"%s\" with %s", swap.FromChildName, swap.OfferedChore, swap.ToChildName),
+		Points:       swap.Points,
+	})
+}
+
+// function for a child to offer one of their chores to a sibling
+func ProposeSwapHandler(db *sql.DB, auth *goauth.AuthService, hub *events.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			children, err := models.GetChildrenByUserID(db, userID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			assignments, err := models.GetAssignmentsByUserID(db, userID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			var mine, theirs []*models.Assignment
+			for _, a := range assignments {
+				if a.IsCompleted {
+					continue
+				}
+				if a.ChildID == child.ID {
+					mine = append(mine, a)
+				} else {
+					theirs = append(theirs, a)
+				}
+			}
+
+			var siblings []*models.Child
+			for _, c := range children {
+				if c.ID != child.ID {
+					siblings = append(siblings, c)
+				}
+			}
+
+			data := struct {
+				Child              *models.Child
+				Siblings           []*models.Child
+				Assignments        []*models.Assignment
+				SiblingAssignments []*models.Assignment
+			}{
+				Child:              child,
+				Siblings:           siblings,
+				Assignments:        mine,
+				SiblingAssignments: theirs,
+			}
+
+			tmpl, err := template.ParseFiles("../../templates/propose_swap.html")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			err = tmpl.Execute(w, data)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			swap := &models.ChoreSwap{
+				UserID:      userID,
+				FromChildID: child.ID,
+				Note:        strings.TrimSpace(r.FormValue("note")),
+			}
+			swap.ToChildID, _ = strconv.ParseInt(r.FormValue("to_child_id"), 10, 64)
+			swap.OfferedAssignmentID, _ = strconv.ParseInt(r.FormValue("assignment_id"), 10, 64)
+			swap.RequestedAssignmentID, _ = strconv.ParseInt(r.FormValue("requested_assignment_id"), 10, 64)
+			swap.Points, _ = strconv.Atoi(r.FormValue("points"))
+
+			err = models.ProposeSwap(db, swap)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			hub.Publish(events.Event{
+				Type:         events.SwapProposed,
+				UserID:       userID,
+				ChildID:      swap.ToChildID,
+				AssignmentID: swap.OfferedAssignmentID,
+				ChildName:    swap.FromChildName,
+				Description:  swap.OfferedChore,
+				Points:       swap.Points,
+			})
+
+			// Trigger refresh and return the Propose a Swap button
+			w.Header().Set("HX-Trigger", "refreshSwaps")
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(swapButton(child.ID)))
+		}
+	}
+}
+
+func SwapActionHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(swapButton(child.ID)))
+	}
+}
+
+// function to show the swaps a child has offered or been offered
+func ChildSwapsHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		child, status, err := pathChild(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		swaps, err := models.GetSwapsByChild(db, child.ID, swapListSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		data := struct {
+			Child *models.Child
+			Swaps []*models.ChoreSwap
+		}{
+			Child: child,
+			Swaps: swaps,
+		}
+
+		tmpl, err := template.ParseFiles("../../templates/child_swaps.html")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = tmpl.Execute(w, data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// function for the sibling to accept a swap; it may still need a parent's approval
+func AcceptSwapHandler(db *sql.DB, auth *goauth.AuthService, hub *events.Hub, notifier *notify.Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		swap, status, err := pathSwap(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		rules, err := models.GetSwapRules(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = models.AcceptSwap(db, swap, rules, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if swap.Status == models.SwapAccepted {
+			notifier.Dispatch(&models.Notification{
+				UserID:  userID,
+				ChildID: swap.ToChildID,
+				Kind:    events.SwapProposed,
+				Title:   "Chore swap needs approval",
+				Body:    fmt.Sprintf("%s and %s want to swap \"%s\"", swap.FromChildName, swap.ToChildName, swap.OfferedChore),
+			})
+		} else {
+			publishSwapCompleted(hub, swap)
+		}
+
+		w.Header().Set("HX-Trigger", "refreshSwaps, refreshChildDashboard, refreshAssignments")
+	}
+}
+
+// function for a parent to approve a swap both children agreed to
+func ApproveSwapHandler(db *sql.DB, auth *goauth.AuthService, hub *events.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		swap, status, err := pathSwap(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		err = models.ApproveSwap(db, swap, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		publishSwapCompleted(hub, swap)
+
+		w.Header().Set("HX-Trigger", "refreshSwaps, refreshAssignments")
+	}
+}
+
+// function to close a swap without carrying it out: the sibling declines,
+// the proposer cancels or a parent rejects it
+func CloseSwapHandler(db *sql.DB, auth *goauth.AuthService, status string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		swap, code, err := pathSwap(db, r, userID)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+
+		err = models.CloseSwap(db, swap, status, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "refreshSwaps")
+	}
+}
+
+// function to list the household's swaps for the parent panel
+func SwapListHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		swaps, err := models.GetSwapsByUserID(db, userID, swapListSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		tmpl, err := template.ParseFiles("../../templates/swap_list.html")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = tmpl.Execute(w, swaps)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func SwapRulesHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		rules, err := models.GetSwapRules(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			tmpl, err := template.ParseFiles("../../templates/swap_rules.html")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			err = tmpl.Execute(w, rules)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			rules.RequireApproval = r.FormValue("require_approval") == "on"
+
+			err = rules.Save(db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(swapRulesButton))
+		}
+	}
+}
+
+func SwapRulesActionHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(swapRulesButton))
+	}
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"Adven-Chores/internal/models"
+	"Adven-Chores/internal/webhooks"
+	"database/sql"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/slate20/goauth"
+)
+
+// number of deliveries shown in the parent panel delivery log
+const deliveryLogSize = 25
+
+func WebhookListHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		hooks, err := models.GetWebhooksByUserID(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		tmpl, err := template.ParseFiles("../../templates/webhook_list.html")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = tmpl.Execute(w, hooks)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func AddWebhookHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			tmpl, err := template.ParseFiles("../../templates/add_webhook.html")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			err = tmpl.Execute(w, webhooks.EventTypes)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			r.ParseForm()
+			url := strings.TrimSpace(r.FormValue("url"))
+			if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+				http.Error(w, "Webhook URL must start with http:// or https://", http.StatusBadRequest)
+				return
+			}
+
+			// Only keep event types we know how to deliver
+			var eventTypes []string
+			for _, t := range r.Form["event_types"] {
+				for _, known := range webhooks.EventTypes {
+					if t == known {
+						eventTypes = append(eventTypes, t)
+					}
+				}
+			}
+			if len(eventTypes) == 0 {
+				http.Error(w, "Select at least one event type", http.StatusBadRequest)
+				return
+			}
+
+			secret, err := webhooks.NewSecret()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			hook := &models.Webhook{
+				UserID:     userID,
+				URL:        url,
+				Secret:     secret,
+				EventTypes: eventTypes,
+			}
+			err = hook.Save(db)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("HX-Trigger", "refreshWebhookList")
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<button class="action-button" hx-get="/add-webhook" hx-target="#webhook-action-container" hx-swap="innerHTML">Add Webhook</button>`))
+		}
+	}
+}
+
+func DeleteWebhookHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		paths := strings.Split(r.URL.Path, "/")
+		id, err := strconv.ParseInt(paths[len(paths)-1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+			return
+		}
+
+		hook, err := models.GetWebhookByID(db, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// check if userID matches hook.UserID and return Unauthorized if not
+		if userID != hook.UserID {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		err = models.DeleteWebhook(db, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "refreshWebhookDeliveries")
+	}
+}
+
+func WebhookActionHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<button class="action-button" hx-get="/add-webhook" hx-target="#webhook-action-container" hx-swap="innerHTML">Add Webhook</button>`))
+	}
+}
+
+func WebhookDeliveriesHandler(db *sql.DB, auth *goauth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := ExtractUserID(r, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		deliveries, err := models.GetWebhookDeliveriesByUserID(db, userID, deliveryLogSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		tmpl, err := template.ParseFiles("../../templates/webhook_deliveries.html")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = tmpl.Execute(w, deliveries)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
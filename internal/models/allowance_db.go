@@ -0,0 +1,301 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// statuses a cash out moves through. Approved cash outs are money the
+// parent still owes the child.
+const (
+	CashOutPending  = "pending"
+	CashOutApproved = "approved"
+	CashOutPaid     = "paid"
+	CashOutRejected = "rejected"
+)
+
+// ways a parent can record paying a cash out
+var PayoutMethods = []string{"Cash", "Bank transfer", "Other"}
+
+var ErrCashOutClosed = errors.New("this cash out has already been settled")
+
+var ErrNotEnoughPoints = errors.New("not enough points")
+
+// function to get a household's allowance settings, falling back to defaults
+func GetAllowanceSettings(db DBTX, userID int) (*AllowanceSettings, error) {
+	s := &AllowanceSettings{UserID: userID, PointsPerUnit: 10, Currency: "$"}
+
+	err := db.QueryRow("SELECT enabled, points_per_unit, currency, min_cash_out FROM allowance_settings WHERE user_id = ?", userID).
+		Scan(&s.Enabled, &s.PointsPerUnit, &s.Currency, &s.MinCashOut)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get allowance settings: %v", err)
+	}
+
+	return s, nil
+}
+
+// function to save a household's allowance settings
+func (s *AllowanceSettings) Save(db *sql.DB) error {
+	if s.PointsPerUnit <= 0 {
+		return fmt.Errorf("points per %s must be at least 1", s.Currency)
+	}
+
+	query := `
+		INSERT INTO allowance_settings (user_id, enabled, points_per_unit, currency, min_cash_out)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			enabled = excluded.enabled,
+			points_per_unit = excluded.points_per_unit,
+			currency = excluded.currency,
+			min_cash_out = excluded.min_cash_out
+	`
+	_, err := db.Exec(query, s.UserID, s.Enabled, s.PointsPerUnit, s.Currency, s.MinCashOut)
+	if err != nil {
+		return fmt.Errorf("failed to save allowance settings: %v", err)
+	}
+	return nil
+}
+
+// function to convert points to an amount in cents, rounding down
+func (s *AllowanceSettings) Value(points int) int {
+	return points * 100 / s.PointsPerUnit
+}
+
+// function to format an amount in cents with the household currency
+func (s *AllowanceSettings) Format(cents int) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%s%d.%02d", sign, s.Currency, cents/100, cents%100)
+}
+
+// function to format what a number of points is worth
+func (s *AllowanceSettings) FormatPoints(points int) string {
+	return s.Format(s.Value(points))
+}
+
+// function to get the smallest number of points a child can cash out
+func (s *AllowanceSettings) CashOutMinimum() int {
+	if s.MinCashOut > 1 {
+		return s.MinCashOut
+	}
+	return 1
+}
+
+const cashOutColumns = `co.id, co.user_id, co.child_id, ch.name, co.points, co.amount_cents, co.status,
+	co.method, co.note, co.requested_at, co.paid_at`
+
+func scanCashOut(row interface{ Scan(...interface{}) error }) (*CashOut, error) {
+	c := &CashOut{}
+	err := row.Scan(&c.ID, &c.UserID, &c.ChildID, &c.ChildName, &c.Points, &c.AmountCents, &c.Status,
+		&c.Method, &c.Note, &c.RequestedAt, &c.PaidAt)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// function to get a cash out by ID
+func GetCashOutByID(db DBTX, id int64) (*CashOut, error) {
+	query := "SELECT " + cashOutColumns + " FROM cash_outs co JOIN children ch ON ch.id = co.child_id WHERE co.id = ?"
+	c, err := scanCashOut(db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no cash out found with ID %d", id)
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// function to query cash outs, newest first
+func queryCashOuts(db DBTX, where string, args ...interface{}) ([]*CashOut, error) {
+	query := "SELECT " + cashOutColumns + " FROM cash_outs co JOIN children ch ON ch.id = co.child_id WHERE " + where +
+		" ORDER BY co.requested_at DESC, co.id DESC LIMIT ?"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cash outs: %v", err)
+	}
+	defer rows.Close()
+
+	var cashOuts []*CashOut
+	for rows.Next() {
+		c, err := scanCashOut(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cash out: %v", err)
+		}
+		cashOuts = append(cashOuts, c)
+	}
+
+	return cashOuts, rows.Err()
+}
+
+// function to get a household's most recent cash outs
+func GetCashOutsByUserID(db DBTX, userID int, limit int) ([]*CashOut, error) {
+	return queryCashOuts(db, "co.user_id = ?", userID, limit)
+}
+
+// function to get a child's most recent cash outs
+func GetCashOutsByChild(db DBTX, childID int64, limit int) ([]*CashOut, error) {
+	return queryCashOuts(db, "co.child_id = ?", childID, limit)
+}
+
+// function to get how much money is owed to each child of a household for
+// cash outs that are approved but not yet paid
+func GetMoneyOwed(db DBTX, userID int) (map[int64]int, error) {
+	rows, err := db.Query("SELECT child_id, SUM(amount_cents) FROM cash_outs WHERE user_id = ? AND status = ? GROUP BY child_id",
+		userID, CashOutApproved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get money owed: %v", err)
+	}
+	defer rows.Close()
+
+	owed := make(map[int64]int)
+	for rows.Next() {
+		var childID int64
+		var cents int
+		err := rows.Scan(&childID, &cents)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan money owed: %v", err)
+		}
+		owed[childID] = cents
+	}
+
+	return owed, rows.Err()
+}
+
+// function for a child to cash out points. The points leave the child's
+// balance straight away and are refunded if the parent rejects the request.
+// The balance is re-read in the transaction so points locked for a savings
+// goal or outside the spend jar can't be cashed out.
+func RequestCashOut(db *sql.DB, child *Child, points int, settings *AllowanceSettings) (*CashOut, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	current, err := GetChildByID(tx, child.ID)
+	if err != nil {
+		return nil, err
+	}
+	*child = *current
+
+	spendable, err := GetSpendablePoints(tx, child)
+	if err != nil {
+		return nil, err
+	}
+	if points > spendable {
+		return nil, ErrNotEnoughPoints
+	}
+
+	child.Points -= points
+	err = child.Save(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	cashOut := &CashOut{
+		UserID:      child.UserID,
+		ChildID:     child.ID,
+		ChildName:   child.Name,
+		Points:      points,
+		AmountCents: settings.Value(points),
+		Status:      CashOutPending,
+	}
+
+	result, err := tx.Exec("INSERT INTO cash_outs (user_id, child_id, points, amount_cents, status) VALUES (?, ?, ?, ?, ?)",
+		cashOut.UserID, cashOut.ChildID, cashOut.Points, cashOut.AmountCents, cashOut.Status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save cash out: %v", err)
+	}
+	cashOut.ID, err = result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	err = RecordPoints(tx, child, -points, PointsCashOut, "Cashed out for "+settings.Format(cashOut.AmountCents))
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit cash out: %v", err)
+	}
+
+	return cashOut, nil
+}
+
+// function for a parent to approve a pending cash out, making it money owed
+func ApproveCashOut(db *sql.DB, id int64) error {
+	result, err := db.Exec("UPDATE cash_outs SET status = ? WHERE id = ? AND status = ?", CashOutApproved, id, CashOutPending)
+	if err != nil {
+		return fmt.Errorf("failed to approve cash out: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrCashOutClosed
+	}
+	return nil
+}
+
+// function for a parent to record paying a cash out. Pending cash outs can be
+// paid directly without approving them first.
+func PayCashOut(db *sql.DB, id int64, method, note string) error {
+	result, err := db.Exec("UPDATE cash_outs SET status = ?, method = ?, note = ?, paid_at = CURRENT_TIMESTAMP WHERE id = ? AND status IN (?, ?)",
+		CashOutPaid, method, note, id, CashOutPending, CashOutApproved)
+	if err != nil {
+		return fmt.Errorf("failed to record payout: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrCashOutClosed
+	}
+	return nil
+}
+
+// function for a parent to reject a cash out and give the points back
+func RejectCashOut(db *sql.DB, id int64, note string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	cashOut, err := GetCashOutByID(tx, id)
+	if err != nil {
+		return err
+	}
+	if cashOut.Status != CashOutPending && cashOut.Status != CashOutApproved {
+		return ErrCashOutClosed
+	}
+
+	_, err = tx.Exec("UPDATE cash_outs SET status = ?, note = ? WHERE id = ?", CashOutRejected, note, id)
+	if err != nil {
+		return fmt.Errorf("failed to reject cash out: %v", err)
+	}
+
+	child, err := GetChildByID(tx, cashOut.ChildID)
+	if err != nil {
+		return err
+	}
+	child.Points += cashOut.Points
+	err = child.Save(tx)
+	if err != nil {
+		return err
+	}
+
+	err = RecordPoints(tx, child, cashOut.Points, PointsRefund, "Cash out declined")
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit cash out: %v", err)
+	}
+	return nil
+}
@@ -3,14 +3,21 @@ package models
 import (
 	"database/sql"
 	"fmt"
+	"slices"
+	"time"
 )
 
 // function to save an assignment to the database
-func (a *Assignment) Save(db *sql.DB) error {
+func (a *Assignment) Save(db DBTX) error {
+	var completedAt interface{}
+	if a.CompletedAt.Valid {
+		completedAt = a.CompletedAt.Time.UTC().Format(timestampLayout)
+	}
+
 	// If the assignment is new, insert it
 	if a.ID == 0 {
-		result, err := db.Exec("INSERT INTO assignments (user_id, child_id, chore_id, is_completed) VALUES (?, ?, ?, ?)",
-			a.Chore.UserID, a.ChildID, a.Chore.ID, a.IsCompleted)
+		result, err := db.Exec("INSERT INTO assignments (user_id, child_id, chore_id, is_completed, due_date, recurrence, completed_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			a.Chore.UserID, a.ChildID, a.Chore.ID, a.IsCompleted, a.DueDate, a.Recurrence, completedAt)
 		if err != nil {
 			return err
 		}
@@ -21,8 +28,8 @@ func (a *Assignment) Save(db *sql.DB) error {
 		}
 	} else {
 		// If the assignment is not new, update it
-		_, err := db.Exec("UPDATE assignments SET child_id = ?, chore_id = ?, is_completed = ? WHERE id = ? AND user_id = ?",
-			a.ChildID, a.Chore.ID, a.IsCompleted, a.ID, a.Chore.UserID)
+		_, err := db.Exec("UPDATE assignments SET child_id = ?, chore_id = ?, is_completed = ?, due_date = ?, recurrence = ?, completed_at = ? WHERE id = ? AND user_id = ?",
+			a.ChildID, a.Chore.ID, a.IsCompleted, a.DueDate, a.Recurrence, completedAt, a.ID, a.Chore.UserID)
 		if err != nil {
 			return err
 		}
@@ -32,11 +39,13 @@ func (a *Assignment) Save(db *sql.DB) error {
 
 // function to retrieve an assignment by ID from the database
 func GetAssignmentByID(db *sql.DB, id int64) (*Assignment, error) {
-	query := "SELECT id, user_id, child_id, chore_id, is_completed FROM assignments WHERE id = ?"
+	query := `SELECT id, user_id, child_id, chore_id, is_completed, due_date, recurrence, completed_at, approved_at, points_awarded
+		FROM assignments WHERE id = ?`
 	row := db.QueryRow(query, id)
 
 	assignment := &Assignment{Chore: &Chore{}}
-	err := row.Scan(&assignment.ID, &assignment.Chore.UserID, &assignment.ChildID, &assignment.Chore.ID, &assignment.IsCompleted)
+	err := row.Scan(&assignment.ID, &assignment.Chore.UserID, &assignment.ChildID, &assignment.Chore.ID, &assignment.IsCompleted,
+		&assignment.DueDate, &assignment.Recurrence, &assignment.CompletedAt, &assignment.ApprovedAt, &assignment.PointsAwarded)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("no assignment found with ID %d", id)
@@ -50,10 +59,12 @@ func GetAssignmentByID(db *sql.DB, id int64) (*Assignment, error) {
 // function to get all assignments from the database
 func GetAllAssignments(db *sql.DB) ([]*Assignment, error) {
 	query := `
-		SELECT a.id, a.child_id, ch.name, c.id, c.description, c.points, c.is_required, a.is_completed
+		SELECT a.id, a.child_id, ch.name, c.id, c.description, c.points, c.is_required, c.category, c.icon, a.is_completed,
+			a.due_date, a.recurrence, EXISTS(SELECT 1 FROM assignment_photos p WHERE p.assignment_id = a.id)
 		FROM assignments a
 		JOIN chores c ON a.chore_id = c.id
 		JOIN children ch ON a.child_id = ch.id
+		WHERE a.approved_at IS NULL
 		`
 
 	rows, err := db.Query(query)
@@ -73,7 +84,12 @@ func GetAllAssignments(db *sql.DB) ([]*Assignment, error) {
 			&a.Chore.Description,
 			&a.Chore.Points,
 			&a.Chore.IsRequired,
+			&a.Chore.Category,
+			&a.Chore.Icon,
 			&a.IsCompleted,
+			&a.DueDate,
+			&a.Recurrence,
+			&a.HasPhoto,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %v", err)
@@ -84,6 +100,11 @@ func GetAllAssignments(db *sql.DB) ([]*Assignment, error) {
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("failed to iterate rows: %v", err)
 	}
+	rows.Close()
+
+	if err = loadAssignmentSteps(db, assignments); err != nil {
+		return nil, err
+	}
 
 	return assignments, nil
 }
@@ -91,11 +112,12 @@ func GetAllAssignments(db *sql.DB) ([]*Assignment, error) {
 // function to get assignments by user ID
 func GetAssignmentsByUserID(db *sql.DB, userID int) ([]*Assignment, error) {
 	query := `
-		SELECT a.id, a.child_id, ch.name, c.id, c.description, c.points, c.is_required, a.is_completed
+		SELECT a.id, a.child_id, ch.name, c.id, c.description, c.points, c.is_required, c.category, c.icon, a.is_completed,
+			a.due_date, a.recurrence, EXISTS(SELECT 1 FROM assignment_photos p WHERE p.assignment_id = a.id)
 		FROM assignments a
 		JOIN chores c ON a.chore_id = c.id
 		JOIN children ch ON a.child_id = ch.id
-		WHERE ch.user_id = ?
+		WHERE ch.user_id = ? AND a.approved_at IS NULL
 		`
 
 	rows, err := db.Query(query, userID)
@@ -115,7 +137,12 @@ func GetAssignmentsByUserID(db *sql.DB, userID int) ([]*Assignment, error) {
 			&a.Chore.Description,
 			&a.Chore.Points,
 			&a.Chore.IsRequired,
+			&a.Chore.Category,
+			&a.Chore.Icon,
 			&a.IsCompleted,
+			&a.DueDate,
+			&a.Recurrence,
+			&a.HasPhoto,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %v", err)
@@ -126,13 +153,24 @@ func GetAssignmentsByUserID(db *sql.DB, userID int) ([]*Assignment, error) {
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("failed to iterate rows: %v", err)
 	}
+	rows.Close()
+
+	if err = loadAssignmentSteps(db, assignments); err != nil {
+		return nil, err
+	}
 
 	return assignments, nil
 }
 
-// function to delete an assignment from the database
+// function to delete an open assignment and its checklist progress from the
+// database; approved assignments are history and can't be deleted
 func DeleteAssignment(db *sql.DB, id int64) error {
-	result, err := db.Exec("DELETE FROM assignments WHERE id = ?", id)
+	_, err := db.Exec("DELETE FROM assignment_steps WHERE assignment_id IN (SELECT id FROM assignments WHERE id = ? AND approved_at IS NULL)", id)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec("DELETE FROM assignments WHERE id = ? AND approved_at IS NULL", id)
 	if err != nil {
 		return err
 	}
@@ -143,14 +181,32 @@ func DeleteAssignment(db *sql.DB, id int64) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no assignment found with ID %d", id)
+		return fmt.Errorf("no open assignment found with ID %d", id)
 	}
 
 	return nil
 }
 
 // function to assign a chore to a child
-func AssignChoreToChild(db *sql.DB, childID int64, choreID int64) error {
+func AssignChoreToChild(db DBTX, childID int64, choreID int64) error {
+	return ScheduleChore(db, childID, choreID, "", "")
+}
+
+// function to assign a chore to a child with an optional due date
+// (YYYY-MM-DD) and recurrence
+func ScheduleChore(db DBTX, childID int64, choreID int64, dueDate, recurrence string) error {
+	if !slices.Contains(Recurrences, recurrence) {
+		return fmt.Errorf("invalid recurrence: %s", recurrence)
+	}
+	if recurrence != "" && dueDate == "" {
+		return fmt.Errorf("a repeating chore needs a due date")
+	}
+	if dueDate != "" {
+		if _, err := time.Parse(DueDateLayout, dueDate); err != nil {
+			return fmt.Errorf("invalid due date: %s", dueDate)
+		}
+	}
+
 	// Check if the child and chore exist
 	child, err := GetChildByID(db, childID)
 	if err != nil {
@@ -161,9 +217,10 @@ func AssignChoreToChild(db *sql.DB, childID int64, choreID int64) error {
 		return fmt.Errorf("chore not found: %v", err)
 	}
 
-	// Check if the assignment already exists
+	// Check if the chore is already on the child's list; approved
+	// assignments are history and don't count
 	var exists bool
-	err = db.QueryRow("SELECT EXISTS(SELECT 1 FROM assignments WHERE child_id = ? AND chore_id = ?)", child.ID, chore.ID).Scan(&exists)
+	err = db.QueryRow("SELECT EXISTS(SELECT 1 FROM assignments WHERE child_id = ? AND chore_id = ? AND approved_at IS NULL)", child.ID, chore.ID).Scan(&exists)
 	if err != nil {
 		return fmt.Errorf("failed to check if assignment exists: %v", err)
 	}
@@ -176,6 +233,8 @@ func AssignChoreToChild(db *sql.DB, childID int64, choreID int64) error {
 		ChildID:     child.ID,
 		Chore:       chore,
 		IsCompleted: false,
+		DueDate:     dueDate,
+		Recurrence:  recurrence,
 	}
 
 	err = assignment.Save(db)
@@ -200,7 +259,7 @@ func UnassignChoreFromChild(db *sql.DB, childID int64, choreID int64) error {
 
 	// Check if the assignment exists
 	var exists bool
-	err = db.QueryRow("SELECT EXISTS(SELECT 1 FROM assignments WHERE child_id = ? AND chore_id = ?)", child.ID, chore.ID).Scan(&exists)
+	err = db.QueryRow("SELECT EXISTS(SELECT 1 FROM assignments WHERE child_id = ? AND chore_id = ? AND approved_at IS NULL)", child.ID, chore.ID).Scan(&exists)
 	if err != nil {
 		return fmt.Errorf("failed to check if assignment exists: %v", err)
 	}
@@ -208,8 +267,12 @@ func UnassignChoreFromChild(db *sql.DB, childID int64, choreID int64) error {
 		return fmt.Errorf("chore %d is not assigned to child %d", chore.ID, child.ID)
 	}
 
-	// Delete the assignment record
-	result, err := db.Exec("DELETE FROM assignments WHERE child_id = ? AND chore_id = ?", child.ID, chore.ID)
+	// Delete the assignment record and its checklist progress
+	_, err = db.Exec("DELETE FROM assignment_steps WHERE assignment_id IN (SELECT id FROM assignments WHERE child_id = ? AND chore_id = ? AND approved_at IS NULL)", child.ID, chore.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete step progress: %v", err)
+	}
+	result, err := db.Exec("DELETE FROM assignments WHERE child_id = ? AND chore_id = ? AND approved_at IS NULL", child.ID, chore.ID)
 	if err != nil {
 		return fmt.Errorf("failed to unassign chore from child: %v", err)
 	}
@@ -237,8 +300,24 @@ func CompleteAssignment(db *sql.DB, id int64) error {
 		return fmt.Errorf("assignment is already completed")
 	}
 
+	assignment.Chore, err = GetChoreByID(db, assignment.Chore.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get chore details: %v", err)
+	}
+
+	// Required checklist steps must be ticked first, unless the chore pays
+	// for whatever was done
+	err = loadAssignmentSteps(db, []*Assignment{assignment})
+	if err != nil {
+		return err
+	}
+	if !assignment.CanComplete() {
+		return ErrStepsIncomplete
+	}
+
 	// Update the assignment record
 	assignment.IsCompleted = true
+	assignment.CompletedAt = sql.NullTime{Time: time.Now(), Valid: true}
 	err = assignment.Save(db)
 	if err != nil {
 		return fmt.Errorf("failed to mark assignment as completed: %v", err)
@@ -255,8 +334,14 @@ func UncompleteAssignment(db *sql.DB, id int64) error {
 		return fmt.Errorf("assignment not found: %v", err)
 	}
 
+	// An approved assignment has already been paid for
+	if assignment.ApprovedAt.Valid {
+		return fmt.Errorf("assignment has already been approved")
+	}
+
 	// Update the assignment record
 	assignment.IsCompleted = false
+	assignment.CompletedAt = sql.NullTime{}
 	err = assignment.Save(db)
 	if err != nil {
 		return fmt.Errorf("failed to unmark assignment as completed: %v", err)
@@ -265,43 +350,111 @@ func UncompleteAssignment(db *sql.DB, id int64) error {
 	return nil
 }
 
-// function to reward and remove an assignment once it has been completed
-func RewardAssignment(db *sql.DB, id int64) error {
+// function to reward an assignment once it has been completed. The
+// assignment is kept as history, recording who approved it and the points
+// awarded.
+func RewardAssignment(db *sql.DB, id int64, approvedBy int) (*AssignmentReward, error) {
 	// Check if the assignment exists
 	assignment, err := GetAssignmentByID(db, id)
 	if err != nil {
-		return fmt.Errorf("assignment not found: %v", err)
+		return nil, fmt.Errorf("assignment not found: %v", err)
 	}
 
 	// Check if the assignment is completed
 	if !assignment.IsCompleted {
-		return fmt.Errorf("assignment is not completed")
+		return nil, fmt.Errorf("assignment is not completed")
+	}
+
+	// Work out the points earned from the chore and its checklist
+	points, err := GetAssignmentPoints(db, id)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get the chore details
 	chore, err := GetChoreByID(db, assignment.Chore.ID)
 	if err != nil {
-		return fmt.Errorf("failed to get chore details: %v", err)
+		return nil, fmt.Errorf("failed to get chore details: %v", err)
+	}
+
+	now := time.Now()
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Mark the assignment approved before paying out so it can only be
+	// rewarded once
+	result, err := tx.Exec(`UPDATE assignments SET approved_at = ?, approved_by = ?, points_awarded = ?, chore_description = ?
+		WHERE id = ? AND approved_at IS NULL`,
+		now.UTC().Format(timestampLayout), approvedBy, points, chore.Description, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve assignment: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("assignment has already been approved")
 	}
 
 	// Update the child's points
-	child, err := GetChildByID(db, assignment.ChildID)
+	child, err := GetChildByID(tx, assignment.ChildID)
 	if err != nil {
-		return fmt.Errorf("failed to get child details: %v", err)
+		return nil, fmt.Errorf("failed to get child details: %v", err)
 	}
-	child.Points += chore.Points
-	err = child.Save(db)
+	levelUp := child.Earn(points)
+	err = child.Save(tx)
 	if err != nil {
-		return fmt.Errorf("failed to update child points: %v", err)
+		return nil, fmt.Errorf("failed to update child points: %v", err)
 	}
 
-	// call DeleteAssignment() to remove the assignment from the database
-	err = DeleteAssignment(db, id)
+	err = RecordPoints(tx, child, points, PointsEarned, chore.Description)
 	if err != nil {
-		return fmt.Errorf("failed to delete assignment: %v", err)
+		return nil, err
 	}
 
-	return nil
+	// Repeating chores come straight back for their next due date
+	if assignment.Recurrence != "" {
+		err = ScheduleChore(tx, assignment.ChildID, chore.ID, assignment.NextDueDate(now), assignment.Recurrence)
+		if err != nil {
+			return nil, fmt.Errorf("failed to schedule next assignment: %v", err)
+		}
+	}
+
+	// Approving the chore may finish one of the child's quests
+	quests, questLevelUp, err := AdvanceQuests(tx, child, chore.ID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit reward: %v", err)
+	}
+
+	return &AssignmentReward{Child: child, Points: points, LevelUp: levelUp || questLevelUp, Quests: quests}, nil
+}
+
+// function to get the points an assignment is currently worth
+func GetAssignmentPoints(db *sql.DB, id int64) (int, error) {
+	assignment, err := GetAssignmentByID(db, id)
+	if err != nil {
+		return 0, fmt.Errorf("assignment not found: %v", err)
+	}
+
+	chore, err := GetChoreByID(db, assignment.Chore.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get chore details: %v", err)
+	}
+
+	err = loadAssignmentSteps(db, []*Assignment{assignment})
+	if err != nil {
+		return 0, err
+	}
+
+	return assignment.EarnedPoints(chore), nil
 }
 
 // function to get all assignments for a child
@@ -314,11 +467,12 @@ func GetAssignmentsByChild(db *sql.DB, childID int64) ([]*Assignment, error) {
 
 	// join the assignments and chores tables
 	query := `
-		SELECT a.id, a.child_id, ch.name, a.chore_id, a.is_completed, c.description, c.points, c.is_required
+		SELECT a.id, a.child_id, ch.name, a.chore_id, a.is_completed, c.description, c.points, c.is_required, c.category, c.icon,
+			c.partial_points, a.due_date, a.recurrence, EXISTS(SELECT 1 FROM assignment_photos p WHERE p.assignment_id = a.id)
 		FROM assignments a
 		JOIN chores c ON a.chore_id = c.id
 		JOIN children ch ON a.child_id = ch.id
-		WHERE a.child_id = ?
+		WHERE a.child_id = ? AND a.approved_at IS NULL
 	`
 
 	rows, err := db.Query(query, childID)
@@ -339,6 +493,12 @@ func GetAssignmentsByChild(db *sql.DB, childID int64) ([]*Assignment, error) {
 			&assignment.Chore.Description,
 			&assignment.Chore.Points,
 			&assignment.Chore.IsRequired,
+			&assignment.Chore.Category,
+			&assignment.Chore.Icon,
+			&assignment.Chore.PartialPoints,
+			&assignment.DueDate,
+			&assignment.Recurrence,
+			&assignment.HasPhoto,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %v", err)
@@ -349,6 +509,11 @@ func GetAssignmentsByChild(db *sql.DB, childID int64) ([]*Assignment, error) {
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating over rows: %v", err)
 	}
+	rows.Close()
+
+	if err = loadAssignmentSteps(db, assignments); err != nil {
+		return nil, err
+	}
 
 	return assignments, nil
 }
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// function to get a page of approved assignments matching a where clause,
+// newest first. Pages start at 1.
+func getAssignmentHistory(db *sql.DB, page, pageSize int, where string, args ...interface{}) (*AssignmentHistory, error) {
+	history := &AssignmentHistory{Page: max(page, 1), PageSize: pageSize}
+
+	from := `
+		FROM assignments a
+		JOIN children ch ON a.child_id = ch.id
+		LEFT JOIN chores c ON a.chore_id = c.id
+		LEFT JOIN users u ON a.approved_by = u.id
+		WHERE a.approved_at IS NOT NULL AND ` + where
+
+	err := db.QueryRow("SELECT COUNT(*)"+from, args...).Scan(&history.Total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count assignment history: %v", err)
+	}
+
+	query := `
+		SELECT a.id, a.child_id, ch.name, a.chore_id, a.chore_description, COALESCE(c.icon, ''), a.due_date, a.recurrence,
+			a.completed_at, a.approved_at, COALESCE(u.username, ''), a.points_awarded` + from + `
+		ORDER BY a.approved_at DESC, a.id DESC
+		LIMIT ? OFFSET ?`
+
+	rows, err := db.Query(query, append(args, pageSize, (history.Page-1)*pageSize)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment history: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a := &Assignment{IsCompleted: true, Chore: &Chore{}}
+		err := rows.Scan(&a.ID, &a.ChildID, &a.ChildName, &a.Chore.ID, &a.Chore.Description, &a.Chore.Icon, &a.DueDate, &a.Recurrence,
+			&a.CompletedAt, &a.ApprovedAt, &a.ApprovedBy, &a.PointsAwarded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan assignment history: %v", err)
+		}
+		history.Assignments = append(history.Assignments, a)
+	}
+
+	return history, rows.Err()
+}
+
+// function to get a page of a child's approved assignments
+func GetChildHistory(db *sql.DB, childID int64, page, pageSize int) (*AssignmentHistory, error) {
+	return getAssignmentHistory(db, page, pageSize, "a.child_id = ?", childID)
+}
+
+// function to get a page of a household's approved assignments of a chore.
+// The chore itself may have been deleted since.
+func GetChoreHistory(db *sql.DB, userID int, choreID int64, page, pageSize int) (*AssignmentHistory, error) {
+	return getAssignmentHistory(db, page, pageSize, "a.user_id = ? AND a.chore_id = ?", userID, choreID)
+}
+
+// function to get the number of pages in the history
+func (h *AssignmentHistory) Pages() int {
+	if h.Total == 0 {
+		return 1
+	}
+	return (h.Total + h.PageSize - 1) / h.PageSize
+}
+
+// function to check whether there is a newer page
+func (h *AssignmentHistory) HasPrevious() bool {
+	return h.Page > 1
+}
+
+// function to check whether there is an older page
+func (h *AssignmentHistory) HasNext() bool {
+	return h.Page < h.Pages()
+}
+
+// function to get the number of the newer page
+func (h *AssignmentHistory) Previous() int {
+	return h.Page - 1
+}
+
+// function to get the number of the older page
+func (h *AssignmentHistory) Next() int {
+	return h.Page + 1
+}
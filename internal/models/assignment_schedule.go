@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+)
+
+// layout of Assignment.DueDate
+const DueDateLayout = "2006-01-02"
+
+// how often an assignment repeats; empty means it doesn't
+const (
+	RecurrenceNone   = ""
+	RecurrenceDaily  = "daily"
+	RecurrenceWeekly = "weekly"
+)
+
+var Recurrences = []string{RecurrenceNone, RecurrenceDaily, RecurrenceWeekly}
+
+// function to get the due date as a time; false when there is no due date
+func (a *Assignment) Due() (time.Time, bool) {
+	if a.DueDate == "" {
+		return time.Time{}, false
+	}
+	due, err := time.ParseInLocation(DueDateLayout, a.DueDate, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return due, true
+}
+
+// function to check whether the assignment falls on a day, taking
+// recurrence into account. Repeating assignments start on their due date.
+func (a *Assignment) OccursOn(day time.Time) bool {
+	due, ok := a.Due()
+	if !ok {
+		return false
+	}
+	day = startOfDay(day)
+	if day.Before(due) {
+		return false
+	}
+
+	switch a.Recurrence {
+	case RecurrenceDaily:
+		return true
+	case RecurrenceWeekly:
+		return day.Weekday() == due.Weekday()
+	}
+	return day.Equal(due)
+}
+
+// function to get the due date of the next occurrence of a repeating
+// assignment, skipping any occurrences that have already passed
+func (a *Assignment) NextDueDate(now time.Time) string {
+	due, ok := a.Due()
+	if !ok {
+		return ""
+	}
+
+	today := startOfDay(now)
+	for {
+		switch a.Recurrence {
+		case RecurrenceDaily:
+			due = due.AddDate(0, 0, 1)
+		case RecurrenceWeekly:
+			due = due.AddDate(0, 0, 7)
+		default:
+			return a.DueDate
+		}
+		if !due.Before(today) {
+			return due.Format(DueDateLayout)
+		}
+	}
+}
+
+// function to check whether the assignment is past its due date and not done
+func (a *Assignment) Overdue() bool {
+	due, ok := a.Due()
+	return ok && !a.IsCompleted && due.Before(startOfDay(time.Now()))
+}
+
+// function to get the start of the local day of a time
+func startOfDay(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,306 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// JobClass is a job a child can pick for their avatar
+type JobClass struct {
+	Key         string
+	Name        string
+	Description string
+}
+
+// Built-in job classes; the first one is used when a child hasn't picked one
+var JobClasses = []*JobClass{
+	{Key: "adventurer", Name: "Adventurer", Description: "Ready for any chore the day brings"},
+	{Key: "knight", Name: "Knight", Description: "Brave defender of a tidy castle"},
+	{Key: "wizard", Name: "Wizard", Description: "Makes messes vanish"},
+	{Key: "ranger", Name: "Ranger", Description: "Keeps the yard and the pets in order"},
+	{Key: "healer", Name: "Healer", Description: "Looks after everyone in the party"},
+	{Key: "pirate", Name: "Pirate", Description: "Hunts for treasure under the sofa"},
+}
+
+// function to get the avatar image of a job class
+func (j *JobClass) Image() string {
+	return "/static/avatars/" + j.Key + ".svg"
+}
+
+// function to look up a job class, falling back to the default class
+func GetJobClass(key string) *JobClass {
+	for _, j := range JobClasses {
+		if j.Key == key {
+			return j
+		}
+	}
+	return JobClasses[0]
+}
+
+// function to get the child's job class. Jobs typed in before classes
+// existed fall back to the default class.
+func (c *Child) JobClass() *JobClass {
+	return GetJobClass(c.Job)
+}
+
+// slots a cosmetic item can be worn in, in the order they are drawn
+const (
+	SlotBackground = "background"
+	SlotPet        = "pet"
+	SlotHat        = "hat"
+)
+
+var CosmeticSlots = []string{SlotHat, SlotPet, SlotBackground}
+
+// Cosmetic is an item that decorates a child's avatar. Items with a price
+// are bought with points; items with an unlock level are free from that level.
+type Cosmetic struct {
+	Key         string
+	Name        string
+	Slot        string
+	Price       int
+	UnlockLevel int
+}
+
+// Built-in cosmetic items
+var Cosmetics = []*Cosmetic{
+	{Key: "party-hat", Name: "Party Hat", Slot: SlotHat, Price: 30},
+	{Key: "wizard-hat", Name: "Wizard Hat", Slot: SlotHat, Price: 60},
+	{Key: "crown", Name: "Crown", Slot: SlotHat, UnlockLevel: 5},
+	{Key: "owl", Name: "Owl", Slot: SlotPet, Price: 40},
+	{Key: "cat", Name: "Cat", Slot: SlotPet, Price: 50},
+	{Key: "dragon", Name: "Baby Dragon", Slot: SlotPet, UnlockLevel: 8},
+	{Key: "meadow", Name: "Meadow", Slot: SlotBackground, Price: 20},
+	{Key: "night-sky", Name: "Night Sky", Slot: SlotBackground, Price: 40},
+	{Key: "castle", Name: "Castle", Slot: SlotBackground, UnlockLevel: 3},
+}
+
+// function to get the image of a cosmetic item
+func (c *Cosmetic) Image() string {
+	return "/static/cosmetics/" + c.Key + ".svg"
+}
+
+// function to look up a cosmetic item; nil when there is no such item
+func GetCosmetic(key string) *Cosmetic {
+	for _, c := range Cosmetics {
+		if c.Key == key {
+			return c
+		}
+	}
+	return nil
+}
+
+// Avatar is what a child looks like: their job class and equipped items
+type Avatar struct {
+	Child      *Child
+	Class      *JobClass
+	Background *Cosmetic
+	Hat        *Cosmetic
+	Pet        *Cosmetic
+}
+
+// function to get a child's bought items and the key equipped in each slot
+func getChildCosmetics(db DBTX, childID int64) (map[string]bool, map[string]string, error) {
+	rows, err := db.Query("SELECT item_key, slot, equipped FROM child_cosmetics WHERE child_id = ?", childID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get cosmetics: %v", err)
+	}
+	defer rows.Close()
+
+	owned := make(map[string]bool)
+	equipped := make(map[string]string)
+	for rows.Next() {
+		var key, slot string
+		var isEquipped bool
+		if err := rows.Scan(&key, &slot, &isEquipped); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan cosmetic: %v", err)
+		}
+		owned[key] = true
+		if isEquipped {
+			equipped[slot] = key
+		}
+	}
+	return owned, equipped, rows.Err()
+}
+
+// function to get a child's avatar
+func GetAvatar(db DBTX, child *Child) (*Avatar, error) {
+	_, equipped, err := getChildCosmetics(db, child.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Avatar{
+		Child:      child,
+		Class:      child.JobClass(),
+		Background: GetCosmetic(equipped[SlotBackground]),
+		Hat:        GetCosmetic(equipped[SlotHat]),
+		Pet:        GetCosmetic(equipped[SlotPet]),
+	}, nil
+}
+
+// function to get the avatars of several children
+func GetAvatars(db DBTX, children []*Child) ([]*Avatar, error) {
+	avatars := make([]*Avatar, 0, len(children))
+	for _, child := range children {
+		avatar, err := GetAvatar(db, child)
+		if err != nil {
+			return nil, err
+		}
+		avatars = append(avatars, avatar)
+	}
+	return avatars, nil
+}
+
+// ShopItem is a cosmetic item as it appears to one child in the shop
+type ShopItem struct {
+	*Cosmetic
+	Owned      bool
+	Equipped   bool
+	Affordable bool
+}
+
+// function to check whether the item still needs a higher level
+func (s *ShopItem) Locked() bool {
+	return !s.Owned && s.Price == 0
+}
+
+// function to list the cosmetic items for a child, marking what they own,
+// what they wear and what they can afford with their spendable points
+func GetCosmeticShop(db DBTX, child *Child, spendable int) ([]*ShopItem, error) {
+	owned, equipped, err := getChildCosmetics(db, child.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*ShopItem, 0, len(Cosmetics))
+	for _, c := range Cosmetics {
+		items = append(items, &ShopItem{
+			Cosmetic:   c,
+			Owned:      owned[c.Key] || c.UnlockedFor(child),
+			Equipped:   equipped[c.Slot] == c.Key,
+			Affordable: c.Price > 0 && c.Price <= spendable,
+		})
+	}
+	return items, nil
+}
+
+// function to check whether the child's level unlocks the item for free
+func (c *Cosmetic) UnlockedFor(child *Child) bool {
+	return c.UnlockLevel > 0 && child.Level() >= c.UnlockLevel
+}
+
+// function to change a child's job class
+func SetJobClass(db DBTX, child *Child, key string) error {
+	job := GetJobClass(key)
+	if job.Key != key {
+		return fmt.Errorf("no job class %s", key)
+	}
+
+	child.Job = job.Key
+	return child.Save(db)
+}
+
+// function to buy a cosmetic item with points. The child's balance and
+// items are re-read in the transaction so an item can't be bought twice and
+// points saved in goals or jars can't be spent.
+func BuyCosmetic(db *sql.DB, child *Child, item *Cosmetic) error {
+	if item.Price <= 0 {
+		return fmt.Errorf("%s can't be bought", item.Name)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	current, err := GetChildByID(tx, child.ID)
+	if err != nil {
+		return err
+	}
+	*child = *current
+
+	owned, _, err := getChildCosmetics(tx, child.ID)
+	if err != nil {
+		return err
+	}
+	if owned[item.Key] {
+		return fmt.Errorf("%s already has %s", child.Name, item.Name)
+	}
+
+	spendable, err := GetSpendablePoints(tx, child)
+	if err != nil {
+		return err
+	}
+	if spendable < item.Price {
+		return fmt.Errorf("%s costs %d points", item.Name, item.Price)
+	}
+
+	child.Points -= item.Price
+	err = child.Save(tx)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO child_cosmetics (child_id, item_key, slot) VALUES (?, ?, ?)", child.ID, item.Key, item.Slot)
+	if err != nil {
+		return fmt.Errorf("failed to save cosmetic: %v", err)
+	}
+
+	err = RecordPoints(tx, child, -item.Price, PointsSpent, "Avatar: "+item.Name)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit purchase: %v", err)
+	}
+	return nil
+}
+
+// function to wear an item the child owns or has unlocked, replacing
+// whatever was in its slot
+func EquipCosmetic(db *sql.DB, child *Child, item *Cosmetic) error {
+	owned, _, err := getChildCosmetics(db, child.ID)
+	if err != nil {
+		return err
+	}
+	if !owned[item.Key] && !item.UnlockedFor(child) {
+		return fmt.Errorf("%s doesn't have %s yet", child.Name, item.Name)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE child_cosmetics SET equipped = 0 WHERE child_id = ? AND slot = ?", child.ID, item.Slot)
+	if err != nil {
+		return fmt.Errorf("failed to unequip cosmetic: %v", err)
+	}
+
+	// Items unlocked by level get their row the first time they are worn
+	_, err = tx.Exec(`INSERT INTO child_cosmetics (child_id, item_key, slot, equipped) VALUES (?, ?, ?, 1)
+		ON CONFLICT(child_id, item_key) DO UPDATE SET equipped = 1`, child.ID, item.Key, item.Slot)
+	if err != nil {
+		return fmt.Errorf("failed to equip cosmetic: %v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit cosmetic: %v", err)
+	}
+	return nil
+}
+
+// function to take off whatever the child wears in a slot
+func UnequipCosmetic(db *sql.DB, childID int64, slot string) error {
+	_, err := db.Exec("UPDATE child_cosmetics SET equipped = 0 WHERE child_id = ? AND slot = ?", childID, slot)
+	if err != nil {
+		return fmt.Errorf("failed to unequip cosmetic: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// layout of the expiry field in the bounty form (a datetime-local input)
+const BountyExpiryLayout = "2006-01-02T15:04"
+
+const bountyColumns = `b.id, b.user_id, b.expires_at, b.max_claims, b.is_expired, b.created_at,
+	c.id, c.user_id, c.description, c.points, c.icon`
+
+func scanBounty(row interface{ Scan(...interface{}) error }) (*Bounty, error) {
+	b := &Bounty{Chore: &Chore{IsBounty: true}}
+	err := row.Scan(&b.ID, &b.UserID, &b.ExpiresAt, &b.MaxClaims, &b.IsExpired, &b.CreatedAt,
+		&b.Chore.ID, &b.Chore.UserID, &b.Chore.Description, &b.Chore.Points, &b.Chore.Icon)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// function to post a new bounty along with the chore behind it
+func (b *Bounty) Save(db *sql.DB, now time.Time) error {
+	b.Chore.Description = strings.TrimSpace(b.Chore.Description)
+	if b.Chore.Description == "" {
+		return fmt.Errorf("a description is required")
+	}
+	if b.Chore.Points <= 0 {
+		return fmt.Errorf("the bounty must be worth some points")
+	}
+	if !b.ExpiresAt.After(now) {
+		return fmt.Errorf("the bounty must expire in the future")
+	}
+	if b.MaxClaims < 0 {
+		return fmt.Errorf("invalid number of claimants")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	b.Chore.UserID = b.UserID
+	b.Chore.IsBounty = true
+	err = b.Chore.Save(tx)
+	if err != nil {
+		return fmt.Errorf("failed to save bounty chore: %v", err)
+	}
+
+	result, err := tx.Exec("INSERT INTO bounties (user_id, chore_id, expires_at, max_claims) VALUES (?, ?, ?, ?)",
+		b.UserID, b.Chore.ID, b.ExpiresAt.UTC().Format(timestampLayout), b.MaxClaims)
+	if err != nil {
+		return fmt.Errorf("failed to save bounty: %v", err)
+	}
+
+	b.ID, err = result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit bounty: %v", err)
+	}
+	return nil
+}
+
+// function to load who has claimed each bounty
+func loadBountyClaims(db DBTX, bounties []*Bounty) error {
+	for _, b := range bounties {
+		rows, err := db.Query(`
+			SELECT bc.child_id, ch.name, bc.claimed_at
+			FROM bounty_claims bc
+			JOIN children ch ON ch.id = bc.child_id
+			WHERE bc.bounty_id = ?
+			ORDER BY bc.claimed_at
+		`, b.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get bounty claims: %v", err)
+		}
+
+		b.Claims = nil
+		for rows.Next() {
+			claim := &BountyClaim{}
+			if err := rows.Scan(&claim.ChildID, &claim.ChildName, &claim.ClaimedAt); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan bounty claim: %v", err)
+			}
+			b.Claims = append(b.Claims, claim)
+		}
+		rows.Close()
+	}
+	return nil
+}
+
+// function to get bounties matching a where clause, with their claims
+func getBounties(db DBTX, where string, args ...interface{}) ([]*Bounty, error) {
+	rows, err := db.Query("SELECT "+bountyColumns+" FROM bounties b JOIN chores c ON c.id = b.chore_id WHERE "+where+" ORDER BY b.expires_at", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bounties: %v", err)
+	}
+
+	var bounties []*Bounty
+	for rows.Next() {
+		b, err := scanBounty(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan bounty: %v", err)
+		}
+		bounties = append(bounties, b)
+	}
+	rows.Close()
+
+	err = loadBountyClaims(db, bounties)
+	if err != nil {
+		return nil, err
+	}
+	return bounties, nil
+}
+
+// function to get a bounty by ID
+func GetBountyByID(db DBTX, id int64) (*Bounty, error) {
+	bounties, err := getBounties(db, "b.id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(bounties) == 0 {
+		return nil, fmt.Errorf("no bounty found with ID %d", id)
+	}
+	return bounties[0], nil
+}
+
+// function to get all of a household's bounties, soonest to expire first
+func GetBountiesByUserID(db DBTX, userID int) ([]*Bounty, error) {
+	return getBounties(db, "b.user_id = ?", userID)
+}
+
+// function to get the bounties children can still claim
+func GetOpenBounties(db DBTX, userID int, now time.Time) ([]*Bounty, error) {
+	return getBounties(db, "b.user_id = ? AND b.is_expired = 0 AND b.expires_at > ?", userID, now.UTC().Format(timestampLayout))
+}
+
+// function to check whether the bounty can still be claimed at a time
+func (b *Bounty) Open(now time.Time) bool {
+	return !b.IsExpired && b.ExpiresAt.After(now)
+}
+
+// function to check whether every spot on the bounty has been taken
+func (b *Bounty) Full() bool {
+	return b.MaxClaims > 0 && len(b.Claims) >= b.MaxClaims
+}
+
+// function to get how many more children can claim the bounty; -1 is unlimited
+func (b *Bounty) SpotsLeft() int {
+	if b.MaxClaims == 0 {
+		return -1
+	}
+	return max(b.MaxClaims-len(b.Claims), 0)
+}
+
+// function to check whether a child has claimed the bounty
+func (b *Bounty) ClaimedBy(childID int64) bool {
+	for _, claim := range b.Claims {
+		if claim.ChildID == childID {
+			return true
+		}
+	}
+	return false
+}
+
+// function to claim a bounty for a child, assigning its chore due on the
+// day the bounty expires
+func ClaimBounty(db *sql.DB, bounty *Bounty, child *Child, now time.Time) error {
+	if !bounty.Open(now) {
+		return fmt.Errorf("this bounty has expired")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Re-check the claims inside the transaction so two children can't
+	// take the last spot
+	err = loadBountyClaims(tx, []*Bounty{bounty})
+	if err != nil {
+		return err
+	}
+	if bounty.ClaimedBy(child.ID) {
+		return fmt.Errorf("%s has already claimed this bounty", child.Name)
+	}
+	if bounty.Full() {
+		return fmt.Errorf("this bounty has already been claimed")
+	}
+
+	_, err = tx.Exec("INSERT INTO bounty_claims (bounty_id, child_id, claimed_at) VALUES (?, ?, ?)",
+		bounty.ID, child.ID, now.UTC().Format(timestampLayout))
+	if err != nil {
+		return fmt.Errorf("failed to claim bounty: %v", err)
+	}
+
+	err = ScheduleChore(tx, child.ID, bounty.Chore.ID, bounty.ExpiresAt.Local().Format(DueDateLayout), "")
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit bounty claim: %v", err)
+	}
+
+	bounty.Claims = append(bounty.Claims, &BountyClaim{ChildID: child.ID, ChildName: child.Name, ClaimedAt: now})
+	return nil
+}
+
+// function to expire bounties that have passed their expiry time. Children
+// who claimed a bounty in time keep its chore. Returns the expired bounties.
+func ExpireBounties(db *sql.DB, now time.Time) ([]*Bounty, error) {
+	bounties, err := getBounties(db, "b.is_expired = 0 AND b.expires_at <= ?", now.UTC().Format(timestampLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range bounties {
+		_, err = db.Exec("UPDATE bounties SET is_expired = 1 WHERE id = ?", b.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expire bounty %d: %v", b.ID, err)
+		}
+		b.IsExpired = true
+	}
+	return bounties, nil
+}
+
+// function to take a bounty down, along with its chore and any claims
+// that haven't been approved yet
+func DeleteBounty(db *sql.DB, bounty *Bounty) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM assignments WHERE chore_id = ? AND approved_at IS NULL",
+		"DELETE FROM bounty_claims WHERE bounty_id = (SELECT id FROM bounties WHERE chore_id = ?)",
+		"DELETE FROM bounties WHERE chore_id = ?",
+		"DELETE FROM chores WHERE id = ?",
+	} {
+		_, err = tx.Exec(query, bounty.Chore.ID)
+		if err != nil {
+			return fmt.Errorf("failed to delete bounty: %v", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit bounty deletion: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+)
+
+// function to generate a random, unguessable feed token
+func newFeedToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate feed token: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// function to get the calendar feed of a household (childID 0) or a child,
+// creating it the first time it is asked for
+func GetCalendarFeed(db *sql.DB, userID int, childID int64) (*CalendarFeed, error) {
+	feed := &CalendarFeed{UserID: userID, ChildID: childID}
+
+	err := db.QueryRow("SELECT token FROM calendar_feeds WHERE user_id = ? AND child_id = ?", userID, childID).Scan(&feed.Token)
+	if err == nil {
+		return feed, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get calendar feed: %v", err)
+	}
+
+	feed.Token, err = newFeedToken()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec("INSERT INTO calendar_feeds (token, user_id, child_id) VALUES (?, ?, ?)", feed.Token, userID, childID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create calendar feed: %v", err)
+	}
+	return feed, nil
+}
+
+// function to look up a calendar feed by its token
+func GetCalendarFeedByToken(db *sql.DB, token string) (*CalendarFeed, error) {
+	feed := &CalendarFeed{Token: token}
+
+	err := db.QueryRow("SELECT user_id, child_id FROM calendar_feeds WHERE token = ?", token).Scan(&feed.UserID, &feed.ChildID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no calendar feed found")
+		}
+		return nil, fmt.Errorf("failed to get calendar feed: %v", err)
+	}
+	return feed, nil
+}
+
+// function to replace a feed's token so the old link stops working
+func ResetCalendarFeed(db *sql.DB, userID int, childID int64) (*CalendarFeed, error) {
+	_, err := db.Exec("DELETE FROM calendar_feeds WHERE user_id = ? AND child_id = ?", userID, childID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reset calendar feed: %v", err)
+	}
+	return GetCalendarFeed(db, userID, childID)
+}
@@ -4,14 +4,15 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"time"
 )
 
 // function to save a child to the database
-func (c *Child) Save(db *sql.DB) error {
+func (c *Child) Save(db DBTX) error {
 	// If the child is new, insert it
 	if c.ID == 0 {
-		result, err := db.Exec("INSERT INTO children (user_id, name, job, points, rewards) VALUES (?, ?, ?, ?, ?)",
-			c.UserID, c.Name, c.Job, c.Points, c.Rewards)
+		result, err := db.Exec("INSERT INTO children (user_id, name, job, points, rewards, experience, birthdate) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			c.UserID, c.Name, c.Job, c.Points, c.Rewards, c.Experience, c.Birthdate)
 		if err != nil {
 			return err
 		}
@@ -23,8 +24,8 @@ func (c *Child) Save(db *sql.DB) error {
 	} else {
 		// If the child is not new, update it
 		log.Printf("Updating child %d for user %d", c.ID, c.UserID)
-		result, err := db.Exec("UPDATE children SET name = ?, job = ?, points = ?, rewards = ? WHERE id = ? AND user_id = ?",
-			c.Name, c.Job, c.Points, c.Rewards, c.ID, c.UserID)
+		result, err := db.Exec("UPDATE children SET name = ?, job = ?, points = ?, rewards = ?, experience = ?, birthdate = ? WHERE id = ? AND user_id = ?",
+			c.Name, c.Job, c.Points, c.Rewards, c.Experience, c.Birthdate, c.ID, c.UserID)
 		if err != nil {
 			log.Printf("Failed to update child %d: %v", c.ID, err)
 			return err
@@ -43,12 +44,12 @@ func (c *Child) Save(db *sql.DB) error {
 }
 
 // function to get a child by ID from the database
-func GetChildByID(db *sql.DB, id int64) (*Child, error) {
-	query := "SELECT id, user_id, name, job, points, rewards FROM children WHERE id = ?"
+func GetChildByID(db DBTX, id int64) (*Child, error) {
+	query := "SELECT id, user_id, name, job, points, rewards, experience, birthdate FROM children WHERE id = ?"
 	row := db.QueryRow(query, id)
 
 	child := &Child{}
-	err := row.Scan(&child.ID, &child.UserID, &child.Name, &child.Job, &child.Points, &child.Rewards)
+	err := row.Scan(&child.ID, &child.UserID, &child.Name, &child.Job, &child.Points, &child.Rewards, &child.Experience, &child.Birthdate)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("no child found with ID %d", id)
@@ -63,7 +64,7 @@ func GetChildByID(db *sql.DB, id int64) (*Child, error) {
 func GetAllChildren(db *sql.DB) ([]*Child, error) {
 	var children []*Child
 
-	rows, err := db.Query("SELECT id, name, job, points, rewards FROM children")
+	rows, err := db.Query("SELECT id, name, job, points, rewards, experience, birthdate FROM children")
 	if err != nil {
 		return nil, err
 	}
@@ -71,7 +72,7 @@ func GetAllChildren(db *sql.DB) ([]*Child, error) {
 
 	for rows.Next() {
 		child := &Child{}
-		err := rows.Scan(&child.ID, &child.Name, &child.Job, &child.Points, &child.Rewards)
+		err := rows.Scan(&child.ID, &child.Name, &child.Job, &child.Points, &child.Rewards, &child.Experience, &child.Birthdate)
 		if err != nil {
 			return nil, err
 		}
@@ -89,7 +90,7 @@ func GetAllChildren(db *sql.DB) ([]*Child, error) {
 func GetChildrenByUserID(db *sql.DB, userID int) ([]*Child, error) {
 	var children []*Child
 
-	rows, err := db.Query("SELECT id, name, job, points, rewards FROM children WHERE user_id = ?", userID)
+	rows, err := db.Query("SELECT id, user_id, name, job, points, rewards, experience, birthdate FROM children WHERE user_id = ?", userID)
 	if err != nil {
 		return nil, err
 	}
@@ -97,7 +98,7 @@ func GetChildrenByUserID(db *sql.DB, userID int) ([]*Child, error) {
 
 	for rows.Next() {
 		child := &Child{}
-		err := rows.Scan(&child.ID, &child.Name, &child.Job, &child.Points, &child.Rewards)
+		err := rows.Scan(&child.ID, &child.UserID, &child.Name, &child.Job, &child.Points, &child.Rewards, &child.Experience, &child.Birthdate)
 		if err != nil {
 			return nil, err
 		}
@@ -113,6 +114,23 @@ func GetChildrenByUserID(db *sql.DB, userID int) ([]*Child, error) {
 
 // function to delete a child from the database
 func DeleteChild(db *sql.DB, id int64) error {
+	_, err := db.Exec("DELETE FROM quest_run_chores WHERE run_id IN (SELECT id FROM quest_runs WHERE child_id = ?)", id)
+	if err != nil {
+		return fmt.Errorf("error deleting quest progress: %v", err)
+	}
+
+	_, err = db.Exec("DELETE FROM chore_swaps WHERE from_child_id = ? OR to_child_id = ?", id, id)
+	if err != nil {
+		return fmt.Errorf("error deleting chore swaps: %v", err)
+	}
+
+	for _, table := range []string{"savings_goals", "reward_targets", "reward_prices", "calendar_feeds", "quest_runs", "child_cosmetics", "bounty_claims"} {
+		_, err := db.Exec("DELETE FROM "+table+" WHERE child_id = ?", id)
+		if err != nil {
+			return fmt.Errorf("error deleting from %s: %v", table, err)
+		}
+	}
+
 	result, err := db.Exec("DELETE FROM children WHERE id = ?", id)
 	if err != nil {
 		return fmt.Errorf("error deleting child: %v", err)
@@ -129,3 +147,38 @@ func DeleteChild(db *sql.DB, id int64) error {
 
 	return nil
 }
+
+// experience needed to gain each level
+const ExperiencePerLevel = 100
+
+// layout of Child.Birthdate
+const BirthdateLayout = "2006-01-02"
+
+// function to get a child's age in whole years; 0 when no birthdate is set
+func (c *Child) Age() int {
+	birth, err := time.Parse(BirthdateLayout, c.Birthdate)
+	if err != nil {
+		return 0
+	}
+
+	now := time.Now()
+	age := now.Year() - birth.Year()
+	if now.Month() < birth.Month() || (now.Month() == birth.Month() && now.Day() < birth.Day()) {
+		age--
+	}
+	return max(age, 0)
+}
+
+// function to add earned points and the same amount of experience to a
+// child; returns true when the experience takes them up a level
+func (c *Child) Earn(points int) bool {
+	level := c.Level()
+	c.Points += points
+	c.Experience += points
+	return c.Level() > level
+}
+
+// function to get a child's level from their lifetime experience
+func (c *Child) Level() int {
+	return c.Experience/ExperiencePerLevel + 1
+}
@@ -4,14 +4,30 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 )
 
+const choreColumns = "id, user_id, description, points, is_required, category, tags, icon, partial_points, difficulty, minutes, is_bounty"
+
+// function to scan a chore row selected with choreColumns
+func scanChore(row interface{ Scan(...interface{}) error }) (*Chore, error) {
+	chore := &Chore{}
+	var tags string
+	err := row.Scan(&chore.ID, &chore.UserID, &chore.Description, &chore.Points, &chore.IsRequired, &chore.Category, &tags, &chore.Icon, &chore.PartialPoints,
+		&chore.Difficulty, &chore.Minutes, &chore.IsBounty)
+	if err != nil {
+		return nil, err
+	}
+	chore.Tags = splitList(tags)
+	return chore, nil
+}
+
 // function to save a chore to the database
-func (c *Chore) Save(db *sql.DB) error {
+func (c *Chore) Save(db DBTX) error {
 	// If the chore is new, insert it
 	if c.ID == 0 {
-		result, err := db.Exec("INSERT INTO chores (user_id, description, points, is_required) VALUES (?, ?, ?, ?)",
-			c.UserID, c.Description, c.Points, c.IsRequired)
+		result, err := db.Exec("INSERT INTO chores (user_id, description, points, is_required, category, tags, icon, partial_points, difficulty, minutes, is_bounty) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			c.UserID, c.Description, c.Points, c.IsRequired, c.Category, strings.Join(c.Tags, ","), c.Icon, c.PartialPoints, c.Difficulty, c.Minutes, c.IsBounty)
 		if err != nil {
 			return err
 		}
@@ -22,8 +38,8 @@ func (c *Chore) Save(db *sql.DB) error {
 		}
 	} else {
 		// If the chore is not new, update it
-		_, err := db.Exec("UPDATE chores SET description = ?, points = ?, is_required = ? WHERE id = ? AND user_id = ?",
-			c.Description, c.Points, c.IsRequired, c.ID, c.UserID)
+		_, err := db.Exec("UPDATE chores SET description = ?, points = ?, is_required = ?, category = ?, tags = ?, icon = ?, partial_points = ?, difficulty = ?, minutes = ? WHERE id = ? AND user_id = ?",
+			c.Description, c.Points, c.IsRequired, c.Category, strings.Join(c.Tags, ","), c.Icon, c.PartialPoints, c.Difficulty, c.Minutes, c.ID, c.UserID)
 		if err != nil {
 			return err
 		}
@@ -32,12 +48,9 @@ func (c *Chore) Save(db *sql.DB) error {
 }
 
 // function to get a chore by ID from the database
-func GetChoreByID(db *sql.DB, id int64) (*Chore, error) {
-	query := "SELECT id, user_id, description, points, is_required FROM chores WHERE id = ?"
-	row := db.QueryRow(query, id)
-
-	chore := &Chore{}
-	err := row.Scan(&chore.ID, &chore.UserID, &chore.Description, &chore.Points, &chore.IsRequired)
+func GetChoreByID(db DBTX, id int64) (*Chore, error) {
+	query := "SELECT " + choreColumns + " FROM chores WHERE id = ?"
+	chore, err := scanChore(db.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("no chore found with ID %d", id)
@@ -54,7 +67,7 @@ func GetAllChores(db *sql.DB) ([]*Chore, error) {
 
 	log.Printf("Getting all chores from database")
 
-	rows, err := db.Query("SELECT id, description, points, is_required FROM chores")
+	rows, err := db.Query("SELECT " + choreColumns + " FROM chores")
 	if err != nil {
 		log.Printf("Error getting all chores from database: %v", err)
 		return nil, err
@@ -62,8 +75,7 @@ func GetAllChores(db *sql.DB) ([]*Chore, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		chore := &Chore{}
-		err := rows.Scan(&chore.ID, &chore.Description, &chore.Points, &chore.IsRequired)
+		chore, err := scanChore(rows)
 		if err != nil {
 			log.Printf("Error scanning row: %v", err)
 			return nil, err
@@ -84,19 +96,19 @@ func GetAllChores(db *sql.DB) ([]*Chore, error) {
 	return chores, nil
 }
 
-// function to get chores by user ID from the database
+// function to get chores by user ID from the database. Chores behind
+// bounties only show up on the bounty board.
 func GetChoresByUserID(db *sql.DB, userID int) ([]*Chore, error) {
 	var chores []*Chore
 
-	rows, err := db.Query("SELECT id, description, points, is_required FROM chores WHERE user_id = ?", userID)
+	rows, err := db.Query("SELECT "+choreColumns+" FROM chores WHERE user_id = ? AND is_bounty = 0", userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		chore := &Chore{}
-		err := rows.Scan(&chore.ID, &chore.Description, &chore.Points, &chore.IsRequired)
+		chore, err := scanChore(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -110,8 +122,23 @@ func GetChoresByUserID(db *sql.DB, userID int) ([]*Chore, error) {
 	return chores, nil
 }
 
-// function to delete a chore from the database
+// function to delete a chore and its checklist from the database
 func DeleteChore(db *sql.DB, id int64) error {
+	_, err := db.Exec("DELETE FROM assignment_steps WHERE step_id IN (SELECT id FROM chore_steps WHERE chore_id = ?)", id)
+	if err != nil {
+		return fmt.Errorf("error deleting chore step progress: %v", err)
+	}
+
+	_, err = db.Exec("DELETE FROM chore_steps WHERE chore_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting chore steps: %v", err)
+	}
+
+	_, err = db.Exec("DELETE FROM quest_chores WHERE chore_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error removing chore from quests: %v", err)
+	}
+
 	result, err := db.Exec("DELETE FROM chores WHERE id = ?", id)
 	if err != nil {
 		return fmt.Errorf("error deleting chore: %v", err)
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// Suggested rooms/categories offered on the chore forms; any text is allowed
+var DefaultChoreCategories = []string{"Kitchen", "Bedroom", "Bathroom", "Living Room", "Laundry", "Yard", "Pets", "Homework"}
+
+// Icons offered on the chore forms
+var ChoreIcons = []string{"🧹", "🍽️", "🛏️", "🧺", "🗑️", "🚿", "🌱", "🐶", "📚", "🧽", "🚗", "🛒"}
+
+// label used for chores without a category
+const Uncategorized = "Other"
+
+// ChoreGroup is a set of chores sharing a category, used for grouped lists
+type ChoreGroup struct {
+	Category string
+	Chores   []*Chore
+}
+
+// ChoreFilter narrows a chore list by category and tag; empty fields match all
+type ChoreFilter struct {
+	Category string
+	Tag      string
+}
+
+// function to get the tags as a comma separated string for editing
+func (c *Chore) TagList() string {
+	return strings.Join(c.Tags, ", ")
+}
+
+// function to check whether a chore has the given tag
+func (c *Chore) HasTag(tag string) bool {
+	for _, t := range c.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// function to parse user-entered tags into a clean, de-duplicated list
+func ParseTags(s string) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		tag = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "#")))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// function to check whether a chore passes the filter
+func (f ChoreFilter) Matches(c *Chore) bool {
+	if f.Category != "" && !strings.EqualFold(categoryOf(c), f.Category) {
+		return false
+	}
+	if f.Tag != "" && !c.HasTag(strings.ToLower(f.Tag)) {
+		return false
+	}
+	return true
+}
+
+// function to keep only the chores that pass the filter
+func FilterChores(chores []*Chore, f ChoreFilter) []*Chore {
+	var filtered []*Chore
+	for _, c := range chores {
+		if f.Matches(c) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// function to group chores by category, sorted by category name with
+// uncategorized chores last
+func GroupChoresByCategory(chores []*Chore) []*ChoreGroup {
+	index := make(map[string]*ChoreGroup)
+	var groups []*ChoreGroup
+	for _, c := range chores {
+		category := categoryOf(c)
+		group, ok := index[category]
+		if !ok {
+			group = &ChoreGroup{Category: category}
+			index[category] = group
+			groups = append(groups, group)
+		}
+		group.Chores = append(group.Chores, c)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Category == Uncategorized || groups[j].Category == Uncategorized {
+			return groups[j].Category == Uncategorized && groups[i].Category != Uncategorized
+		}
+		return groups[i].Category < groups[j].Category
+	})
+
+	return groups
+}
+
+// function to list the distinct categories used by a set of chores
+func ChoreCategories(chores []*Chore) []string {
+	seen := make(map[string]bool)
+	var categories []string
+	for _, c := range chores {
+		category := categoryOf(c)
+		if !seen[category] {
+			seen[category] = true
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// function to list the distinct tags used by a set of chores
+func ChoreTags(chores []*Chore) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, c := range chores {
+		for _, t := range c.Tags {
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func categoryOf(c *Chore) string {
+	if c.Category == "" {
+		return Uncategorized
+	}
+	return c.Category
+}
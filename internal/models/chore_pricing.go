@@ -0,0 +1,148 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+)
+
+// names of the chore difficulties, indexed by Chore.Difficulty
+var DifficultyNames = []string{"", "Easy", "Medium", "Hard"}
+
+// chores paying this far above or below the household average are flagged
+const (
+	overpaidRatio  = 1.25
+	underpaidRatio = 0.8
+)
+
+// function to get the name of the chore's difficulty; empty when unset
+func (c *Chore) DifficultyName() string {
+	if c.Difficulty <= 0 || c.Difficulty >= len(DifficultyNames) {
+		return ""
+	}
+	return DifficultyNames[c.Difficulty]
+}
+
+// function to get a household's chore pricing formula, falling back to defaults
+func GetChorePricing(db DBTX, userID int) (*ChorePricing, error) {
+	p := &ChorePricing{UserID: userID, PointsPerMinute: 1, MediumMultiplier: 1.5, HardMultiplier: 2}
+
+	err := db.QueryRow("SELECT points_per_minute, medium_multiplier, hard_multiplier FROM chore_pricing WHERE user_id = ?", userID).
+		Scan(&p.PointsPerMinute, &p.MediumMultiplier, &p.HardMultiplier)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get chore pricing: %v", err)
+	}
+
+	return p, nil
+}
+
+// function to save a household's chore pricing formula
+func (p *ChorePricing) Save(db *sql.DB) error {
+	if p.PointsPerMinute <= 0 || p.MediumMultiplier <= 0 || p.HardMultiplier <= 0 {
+		return fmt.Errorf("points per minute and multipliers must be above zero")
+	}
+
+	_, err := db.Exec(`INSERT INTO chore_pricing (user_id, points_per_minute, medium_multiplier, hard_multiplier) VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			points_per_minute = excluded.points_per_minute,
+			medium_multiplier = excluded.medium_multiplier,
+			hard_multiplier = excluded.hard_multiplier`,
+		p.UserID, p.PointsPerMinute, p.MediumMultiplier, p.HardMultiplier)
+	if err != nil {
+		return fmt.Errorf("failed to save chore pricing: %v", err)
+	}
+	return nil
+}
+
+// function to get the multiplier for a difficulty; easy and unset count as 1
+func (p *ChorePricing) Multiplier(difficulty int) float64 {
+	switch difficulty {
+	case 2:
+		return p.MediumMultiplier
+	case 3:
+		return p.HardMultiplier
+	}
+	return 1
+}
+
+// function to suggest points for a chore from its minutes and difficulty.
+// Returns 0 when the chore has no time estimate.
+func (p *ChorePricing) Suggest(minutes, difficulty int) int {
+	if minutes <= 0 {
+		return 0
+	}
+	return max(1, int(math.Round(float64(minutes)*p.PointsPerMinute*p.Multiplier(difficulty))))
+}
+
+// how a chore's pay compares to the rest of the household's chores
+type ChoreBalance struct {
+	Chore     *Chore
+	PerMinute float64
+	// Ratio is PerMinute divided by the household average; 0 without a time estimate
+	Ratio     float64
+	Suggested int
+}
+
+// function to compare each chore's points per minute with the household
+// average, weighted by minutes. Chores without a time estimate are listed
+// but left out of the average.
+func RebalanceChores(chores []*Chore, pricing *ChorePricing) ([]*ChoreBalance, float64) {
+	var points, minutes int
+	for _, chore := range chores {
+		if chore.Minutes > 0 {
+			points += chore.Points
+			minutes += chore.Minutes
+		}
+	}
+
+	average := 0.0
+	if minutes > 0 {
+		average = float64(points) / float64(minutes)
+	}
+
+	balances := make([]*ChoreBalance, 0, len(chores))
+	for _, chore := range chores {
+		b := &ChoreBalance{Chore: chore, Suggested: pricing.Suggest(chore.Minutes, chore.Difficulty)}
+		if chore.Minutes > 0 {
+			b.PerMinute = float64(chore.Points) / float64(chore.Minutes)
+			if average > 0 {
+				b.Ratio = b.PerMinute / average
+			}
+		}
+		balances = append(balances, b)
+	}
+
+	return balances, average
+}
+
+// function to flag chores that pay well above ("overpaid") or below
+// ("underpaid") the household average
+func (b *ChoreBalance) Flag() string {
+	switch {
+	case b.Chore.Minutes <= 0 || b.Ratio == 0:
+		return ""
+	case b.Ratio > overpaidRatio:
+		return "overpaid"
+	case b.Ratio < underpaidRatio:
+		return "underpaid"
+	}
+	return ""
+}
+
+// function to describe how a chore's pay compares to the household average
+func (b *ChoreBalance) Verdict() string {
+	switch {
+	case b.Chore.Minutes <= 0:
+		return "no time estimate"
+	case b.Flag() == "overpaid":
+		return "pays more than average"
+	case b.Flag() == "underpaid":
+		return "pays less than average"
+	}
+	return "about average"
+}
+
+// function to get the ratio as a whole percentage of the household average
+func (b *ChoreBalance) Percent() int {
+	return int(math.Round(b.Ratio * 100))
+}
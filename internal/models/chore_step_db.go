@@ -0,0 +1,224 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// returned when a child tries to complete a chore with unchecked required steps
+var ErrStepsIncomplete = errors.New("all required steps must be checked before completing this chore")
+
+// function to save a checklist step; new steps are added to the end of the list
+func (s *ChoreStep) Save(db *sql.DB) error {
+	if s.ID == 0 {
+		err := db.QueryRow("SELECT COALESCE(MAX(position), 0) + 1 FROM chore_steps WHERE chore_id = ?", s.ChoreID).Scan(&s.Position)
+		if err != nil {
+			return err
+		}
+
+		result, err := db.Exec("INSERT INTO chore_steps (chore_id, position, description, is_required) VALUES (?, ?, ?, ?)",
+			s.ChoreID, s.Position, s.Description, s.IsRequired)
+		if err != nil {
+			return err
+		}
+
+		s.ID, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+	} else {
+		_, err := db.Exec("UPDATE chore_steps SET position = ?, description = ?, is_required = ? WHERE id = ?",
+			s.Position, s.Description, s.IsRequired, s.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// function to get a checklist step by ID from the database
+func GetChoreStepByID(db *sql.DB, id int64) (*ChoreStep, error) {
+	query := "SELECT id, chore_id, position, description, is_required FROM chore_steps WHERE id = ?"
+
+	s := &ChoreStep{}
+	err := db.QueryRow(query, id).Scan(&s.ID, &s.ChoreID, &s.Position, &s.Description, &s.IsRequired)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no chore step found with ID %d", id)
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// function to get a chore's checklist in order
+func GetChoreSteps(db *sql.DB, choreID int64) ([]*ChoreStep, error) {
+	rows, err := db.Query("SELECT id, chore_id, position, description, is_required FROM chore_steps WHERE chore_id = ? ORDER BY position, id", choreID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var steps []*ChoreStep
+	for rows.Next() {
+		s := &ChoreStep{}
+		err := rows.Scan(&s.ID, &s.ChoreID, &s.Position, &s.Description, &s.IsRequired)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return steps, nil
+}
+
+// function to delete a checklist step and any progress on it
+func DeleteChoreStep(db *sql.DB, id int64) error {
+	_, err := db.Exec("DELETE FROM assignment_steps WHERE step_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting step progress: %v", err)
+	}
+
+	result, err := db.Exec("DELETE FROM chore_steps WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting chore step: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no chore step found with ID %d", id)
+	}
+
+	return nil
+}
+
+// function to move a step one place up (delta -1) or down (delta 1) in its checklist
+func MoveChoreStep(db *sql.DB, id int64, delta int) error {
+	step, err := GetChoreStepByID(db, id)
+	if err != nil {
+		return err
+	}
+
+	steps, err := GetChoreSteps(db, step.ChoreID)
+	if err != nil {
+		return err
+	}
+
+	for i, s := range steps {
+		if s.ID != id {
+			continue
+		}
+		j := i + delta
+		if j < 0 || j >= len(steps) {
+			return nil
+		}
+		steps[i], steps[j] = steps[j], steps[i]
+		break
+	}
+
+	// Renumber the whole list so positions stay contiguous
+	for i, s := range steps {
+		s.Position = i + 1
+		if err := s.Save(db); err != nil {
+			return fmt.Errorf("failed to reorder steps: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// function to tick or untick a step for an assignment
+func SetAssignmentStep(db *sql.DB, assignmentID int64, stepID int64, checked bool) error {
+	var err error
+	if checked {
+		_, err = db.Exec("INSERT OR IGNORE INTO assignment_steps (assignment_id, step_id) VALUES (?, ?)", assignmentID, stepID)
+	} else {
+		_, err = db.Exec("DELETE FROM assignment_steps WHERE assignment_id = ? AND step_id = ?", assignmentID, stepID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update step progress: %v", err)
+	}
+	return nil
+}
+
+// function to load each assignment's checklist along with its progress
+func loadAssignmentSteps(db DBTX, assignments []*Assignment) error {
+	query := `
+		SELECT s.id, s.description, s.is_required, p.step_id IS NOT NULL
+		FROM chore_steps s
+		LEFT JOIN assignment_steps p ON p.step_id = s.id AND p.assignment_id = ?
+		WHERE s.chore_id = ?
+		ORDER BY s.position, s.id
+	`
+
+	for _, a := range assignments {
+		rows, err := db.Query(query, a.ID, a.Chore.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get assignment steps: %v", err)
+		}
+
+		a.Steps = nil
+		for rows.Next() {
+			step := &AssignmentStep{}
+			err := rows.Scan(&step.StepID, &step.Description, &step.IsRequired, &step.IsChecked)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan row: %v", err)
+			}
+			a.Steps = append(a.Steps, step)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("failed to iterate rows: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// function to count the ticked steps of an assignment
+func (a *Assignment) CheckedSteps() int {
+	checked := 0
+	for _, s := range a.Steps {
+		if s.IsChecked {
+			checked++
+		}
+	}
+	return checked
+}
+
+// function to check whether every required step has been ticked
+func (a *Assignment) RequiredStepsDone() bool {
+	for _, s := range a.Steps {
+		if s.IsRequired && !s.IsChecked {
+			return false
+		}
+	}
+	return true
+}
+
+// function to check whether the assignment can be marked complete; chores
+// with partial points can be handed in with required steps still open
+func (a *Assignment) CanComplete() bool {
+	return a.Chore.PartialPoints || a.RequiredStepsDone()
+}
+
+// function to work out the points an assignment is worth given its progress;
+// chores with partial points pay for each ticked step, others pay in full
+func (a *Assignment) EarnedPoints(chore *Chore) int {
+	if !chore.PartialPoints || len(a.Steps) == 0 {
+		return chore.Points
+	}
+	return chore.Points * a.CheckedSteps() / len(a.Steps)
+}
@@ -0,0 +1,170 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Built-in catalogue of age-appropriate chores; MaxAge 0 means no upper limit
+var BuiltinChoreTemplates = []*ChoreTemplate{
+	{Description: "Put toys away", Points: 5, IsRequired: true, Category: "Bedroom", MinAge: 3, MaxAge: 6},
+	{Description: "Feed the pet", Points: 5, IsRequired: false, Category: "Pets", MinAge: 3, MaxAge: 0},
+	{Description: "Put dirty clothes in the hamper", Points: 5, IsRequired: true, Category: "Laundry", MinAge: 3, MaxAge: 8},
+	{Description: "Water the plants", Points: 5, IsRequired: false, Category: "Yard", MinAge: 4, MaxAge: 0},
+	{Description: "Make your bed", Points: 5, IsRequired: true, Category: "Bedroom", MinAge: 4, MaxAge: 0},
+	{Description: "Set the table", Points: 10, IsRequired: false, Category: "Kitchen", MinAge: 5, MaxAge: 0},
+	{Description: "Clear the table", Points: 10, IsRequired: false, Category: "Kitchen", MinAge: 5, MaxAge: 0},
+	{Description: "Match socks from the laundry", Points: 5, IsRequired: false, Category: "Laundry", MinAge: 5, MaxAge: 9},
+	{Description: "Tidy your bedroom", Points: 15, IsRequired: true, Category: "Bedroom", MinAge: 6, MaxAge: 0},
+	{Description: "Empty the dishwasher", Points: 15, IsRequired: false, Category: "Kitchen", MinAge: 7, MaxAge: 0},
+	{Description: "Take out the trash", Points: 10, IsRequired: false, Category: "Kitchen", MinAge: 7, MaxAge: 0},
+	{Description: "Sweep the kitchen floor", Points: 15, IsRequired: false, Category: "Kitchen", MinAge: 8, MaxAge: 0},
+	{Description: "Fold and put away laundry", Points: 20, IsRequired: false, Category: "Laundry", MinAge: 8, MaxAge: 0},
+	{Description: "Vacuum the living room", Points: 20, IsRequired: false, Category: "Living Room", MinAge: 9, MaxAge: 0},
+	{Description: "Clean the bathroom sink", Points: 20, IsRequired: false, Category: "Bathroom", MinAge: 9, MaxAge: 0},
+	{Description: "Rake the leaves", Points: 25, IsRequired: false, Category: "Yard", MinAge: 10, MaxAge: 0},
+	{Description: "Wash the dishes", Points: 20, IsRequired: false, Category: "Kitchen", MinAge: 10, MaxAge: 0},
+	{Description: "Do your own laundry", Points: 25, IsRequired: false, Category: "Laundry", MinAge: 12, MaxAge: 0},
+	{Description: "Mow the lawn", Points: 40, IsRequired: false, Category: "Yard", MinAge: 13, MaxAge: 0},
+	{Description: "Cook a family dinner", Points: 50, IsRequired: false, Category: "Kitchen", MinAge: 14, MaxAge: 0},
+}
+
+func init() {
+	for _, t := range BuiltinChoreTemplates {
+		t.IsBuiltin = true
+	}
+}
+
+// function to check whether a template suits a child of the given age; an age
+// of 0 matches every template
+func (t *ChoreTemplate) SuitsAge(age int) bool {
+	if age == 0 {
+		return true
+	}
+	return age >= t.MinAge && (t.MaxAge == 0 || age <= t.MaxAge)
+}
+
+// function to save a user chore template to the database
+func (t *ChoreTemplate) Save(db DBTX) error {
+	if t.IsBuiltin {
+		return fmt.Errorf("built-in chore templates cannot be saved")
+	}
+
+	if t.ID == 0 {
+		result, err := db.Exec("INSERT INTO chore_templates (user_id, description, points, is_required, category, min_age, max_age) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			t.UserID, t.Description, t.Points, t.IsRequired, t.Category, t.MinAge, t.MaxAge)
+		if err != nil {
+			return err
+		}
+
+		t.ID, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+	} else {
+		_, err := db.Exec("UPDATE chore_templates SET description = ?, points = ?, is_required = ?, category = ?, min_age = ?, max_age = ? WHERE id = ? AND user_id = ?",
+			t.Description, t.Points, t.IsRequired, t.Category, t.MinAge, t.MaxAge, t.ID, t.UserID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// function to get a user chore template by ID from the database
+func GetChoreTemplateByID(db *sql.DB, id int64) (*ChoreTemplate, error) {
+	query := "SELECT id, user_id, description, points, is_required, category, min_age, max_age FROM chore_templates WHERE id = ?"
+
+	t := &ChoreTemplate{}
+	err := db.QueryRow(query, id).Scan(&t.ID, &t.UserID, &t.Description, &t.Points, &t.IsRequired, &t.Category, &t.MinAge, &t.MaxAge)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no chore template found with ID %d", id)
+		}
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// function to get the templates a user has saved
+func GetChoreTemplatesByUserID(db *sql.DB, userID int) ([]*ChoreTemplate, error) {
+	rows, err := db.Query("SELECT id, user_id, description, points, is_required, category, min_age, max_age FROM chore_templates WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []*ChoreTemplate
+	for rows.Next() {
+		t := &ChoreTemplate{}
+		err := rows.Scan(&t.ID, &t.UserID, &t.Description, &t.Points, &t.IsRequired, &t.Category, &t.MinAge, &t.MaxAge)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// function to delete a user chore template from the database
+func DeleteChoreTemplate(db *sql.DB, id int64) error {
+	result, err := db.Exec("DELETE FROM chore_templates WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting chore template: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no chore template found with ID %d", id)
+	}
+
+	return nil
+}
+
+// NewChore pairs a chore to be created with the child it should be assigned
+// to; ChildID 0 leaves the chore unassigned
+type NewChore struct {
+	Chore   *Chore
+	ChildID int64
+}
+
+// function to create several chores, and optionally their assignments, in a
+// single transaction so a failure leaves no partial batch behind
+func CreateChores(db *sql.DB, batch []NewChore) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, item := range batch {
+		err = item.Chore.Save(tx)
+		if err != nil {
+			return fmt.Errorf("failed to save chore %q: %v", item.Chore.Description, err)
+		}
+
+		if item.ChildID != 0 {
+			err = AssignChoreToChild(tx, item.ChildID, item.Chore.ID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit chores: %v", err)
+	}
+
+	return nil
+}
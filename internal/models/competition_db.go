@@ -0,0 +1,225 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const competitionColumns = `c.id, c.user_id, c.name, c.metric, c.start_date, c.end_date, c.prize_reward_id, COALESCE(r.description, ''), c.is_closed, c.winners`
+
+func scanCompetition(row interface{ Scan(...interface{}) error }) (*Competition, error) {
+	c := &Competition{}
+	err := row.Scan(&c.ID, &c.UserID, &c.Name, &c.Metric, &c.StartDate, &c.EndDate, &c.PrizeRewardID, &c.PrizeName, &c.IsClosed, &c.Winners)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// function to save a new competition
+func (c *Competition) Save(db *sql.DB) error {
+	if strings.TrimSpace(c.Name) == "" {
+		return fmt.Errorf("a name is required")
+	}
+	if !slices.Contains(Metrics, c.Metric) {
+		return fmt.Errorf("invalid metric: %s", c.Metric)
+	}
+	start, err := time.ParseInLocation(DueDateLayout, c.StartDate, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid start date: %s", c.StartDate)
+	}
+	end, err := time.ParseInLocation(DueDateLayout, c.EndDate, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid end date: %s", c.EndDate)
+	}
+	if end.Before(start) {
+		return fmt.Errorf("the competition must end on or after its start date")
+	}
+
+	result, err := db.Exec("INSERT INTO competitions (user_id, name, metric, start_date, end_date, prize_reward_id) VALUES (?, ?, ?, ?, ?, ?)",
+		c.UserID, c.Name, c.Metric, c.StartDate, c.EndDate, c.PrizeRewardID)
+	if err != nil {
+		return fmt.Errorf("failed to save competition: %v", err)
+	}
+
+	c.ID, err = result.LastInsertId()
+	return err
+}
+
+// function to get a competition by ID
+func GetCompetitionByID(db DBTX, id int64) (*Competition, error) {
+	row := db.QueryRow("SELECT "+competitionColumns+" FROM competitions c LEFT JOIN rewards r ON r.id = c.prize_reward_id WHERE c.id = ?", id)
+	c, err := scanCompetition(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no competition found with ID %d", id)
+		}
+		return nil, fmt.Errorf("failed to get competition: %v", err)
+	}
+	return c, nil
+}
+
+// function to get a household's competitions, running ones first
+func GetCompetitionsByUserID(db DBTX, userID int) ([]*Competition, error) {
+	rows, err := db.Query("SELECT "+competitionColumns+` FROM competitions c LEFT JOIN rewards r ON r.id = c.prize_reward_id
+		WHERE c.user_id = ? ORDER BY c.is_closed, c.end_date DESC, c.id DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get competitions: %v", err)
+	}
+	defer rows.Close()
+
+	var competitions []*Competition
+	for rows.Next() {
+		c, err := scanCompetition(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan competition: %v", err)
+		}
+		competitions = append(competitions, c)
+	}
+	return competitions, rows.Err()
+}
+
+// function to delete a competition
+func DeleteCompetition(db *sql.DB, id int64) error {
+	_, err := db.Exec("DELETE FROM competitions WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete competition: %v", err)
+	}
+	return nil
+}
+
+// function to get the time span of a competition, from the start of its
+// first day up to the end of its last day
+func (c *Competition) Span() (time.Time, time.Time) {
+	start, _ := time.ParseInLocation(DueDateLayout, c.StartDate, time.Local)
+	end, _ := time.ParseInLocation(DueDateLayout, c.EndDate, time.Local)
+	return start, end.AddDate(0, 0, 1)
+}
+
+// function to check whether a competition has started but not yet closed
+func (c *Competition) Running(now time.Time) bool {
+	start, end := c.Span()
+	return !c.IsClosed && !now.Before(start) && now.Before(end)
+}
+
+// function to get the current standings of a competition
+func (c *Competition) Standings(db DBTX) ([]*Standing, error) {
+	start, end := c.Span()
+	return GetStandings(db, c.UserID, start, end, c.Metric)
+}
+
+// function to describe the competition's metric
+func (c *Competition) MetricLabel() string {
+	return MetricLabels[c.Metric]
+}
+
+// function to close every competition that has ended, granting the prize to
+// the winners. Children tied for first place all win. Returns the
+// competitions that were closed.
+func CloseCompetitions(db *sql.DB, now time.Time) ([]*Competition, error) {
+	rows, err := db.Query("SELECT "+competitionColumns+` FROM competitions c LEFT JOIN rewards r ON r.id = c.prize_reward_id
+		WHERE c.is_closed = 0 AND c.end_date < ?`, now.In(time.Local).Format(DueDateLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ended competitions: %v", err)
+	}
+
+	var ended []*Competition
+	for rows.Next() {
+		c, err := scanCompetition(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan competition: %v", err)
+		}
+		ended = append(ended, c)
+	}
+	rows.Close()
+
+	var closed []*Competition
+	for _, c := range ended {
+		err := closeCompetition(db, c, now)
+		if err != nil {
+			return closed, err
+		}
+		closed = append(closed, c)
+	}
+	return closed, nil
+}
+
+func closeCompetition(db *sql.DB, c *Competition, now time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	standings, err := c.Standings(tx)
+	if err != nil {
+		return err
+	}
+
+	var prize *Reward
+	if c.PrizeRewardID != 0 {
+		prize, err = GetRewardByID(tx, c.PrizeRewardID)
+		if err != nil || prize.UserID != c.UserID {
+			// the prize was deleted since the competition was set up
+			prize = nil
+		}
+	}
+
+	var names []string
+	for _, leader := range Leaders(standings, c.Metric) {
+		names = append(names, leader.ChildName)
+		if prize == nil {
+			continue
+		}
+
+		child, err := GetChildByID(tx, leader.ChildID)
+		if err != nil {
+			return err
+		}
+		err = grantReward(tx, child, prize, prize.Description+" (prize: "+c.Name+")", now)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.IsClosed = true
+	c.Winners = strings.Join(names, ", ")
+	_, err = tx.Exec("UPDATE competitions SET is_closed = 1, winners = ? WHERE id = ?", c.Winners, c.ID)
+	if err != nil {
+		return fmt.Errorf("failed to close competition: %v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit competition: %v", err)
+	}
+	return nil
+}
+
+// function to give a child a reward as a prize, without charging points or
+// checking the reward's limits
+func grantReward(db DBTX, child *Child, reward *Reward, description string, now time.Time) error {
+	if child.Rewards == "" {
+		child.Rewards = reward.Description
+	} else {
+		child.Rewards += ", " + reward.Description
+	}
+
+	err := child.Save(db)
+	if err != nil {
+		return err
+	}
+
+	redemption := &RewardRedemption{
+		UserID:      child.UserID,
+		ChildID:     child.ID,
+		RewardID:    reward.ID,
+		Description: description,
+		RedeemedAt:  now,
+	}
+	return redemption.Save(db)
+}
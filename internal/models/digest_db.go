@@ -0,0 +1,183 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// a child's week in the family digest
+type ChildDigest struct {
+	Child *Child
+	// Completed holds the chores approved during the week
+	Completed []*Assignment
+	// Missed holds the required chores due during the week that weren't
+	// finished by their due date
+	Missed      []*Assignment
+	Redemptions []*RewardRedemption
+}
+
+// a household's weekly summary of each child's progress
+type Digest struct {
+	UserID   int
+	Username string
+	// Start is the Monday the digest week begins on
+	Start    time.Time
+	Children []*ChildDigest
+}
+
+// function to get the last day of the digest week
+func (d *Digest) End() time.Time {
+	return d.Start.AddDate(0, 0, 6)
+}
+
+// function to get the Monday before the digest week
+func (d *Digest) Previous() time.Time {
+	return d.Start.AddDate(0, 0, -7)
+}
+
+// function to get the Monday after the digest week
+func (d *Digest) Next() time.Time {
+	return d.Start.AddDate(0, 0, 7)
+}
+
+// function to get the points the child earned from approved chores
+func (c *ChildDigest) PointsEarned() int {
+	total := 0
+	for _, a := range c.Completed {
+		total += a.PointsAwarded
+	}
+	return total
+}
+
+// function to get the Monday starting the last full week before now
+func DigestWeek(now time.Time) time.Time {
+	return PeriodStart("week", now.In(time.Local)).AddDate(0, 0, -7)
+}
+
+// function to build a household's digest for the week starting on start
+func GetDigest(db *sql.DB, userID int, start time.Time) (*Digest, error) {
+	start = PeriodStart("week", start.In(time.Local))
+	d := &Digest{UserID: userID, Start: start}
+	end := start.AddDate(0, 0, 7)
+	from, to := start.UTC().Format(timestampLayout), end.UTC().Format(timestampLayout)
+
+	err := db.QueryRow("SELECT username FROM users WHERE id = ?", userID).Scan(&d.Username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+
+	children, err := GetChildrenByUserID(db, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get children: %v", err)
+	}
+
+	byChild := make(map[int64]*ChildDigest)
+	for _, child := range children {
+		c := &ChildDigest{Child: child}
+		d.Children = append(d.Children, c)
+		byChild[child.ID] = c
+	}
+
+	rows, err := db.Query(`
+		SELECT a.child_id, a.chore_description, a.approved_at, a.points_awarded
+		FROM assignments a
+		WHERE a.user_id = ? AND a.approved_at >= ? AND a.approved_at < ?
+		ORDER BY a.approved_at
+	`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get completed chores: %v", err)
+	}
+	for rows.Next() {
+		a := &Assignment{IsCompleted: true, Chore: &Chore{}}
+		if err := rows.Scan(&a.ChildID, &a.Chore.Description, &a.ApprovedAt, &a.PointsAwarded); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan completed chore: %v", err)
+		}
+		if c, ok := byChild[a.ChildID]; ok {
+			c.Completed = append(c.Completed, a)
+		}
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT a.child_id, c.description, a.due_date, a.completed_at
+		FROM assignments a
+		JOIN chores c ON a.chore_id = c.id
+		WHERE a.user_id = ? AND c.is_required = 1 AND a.due_date >= ? AND a.due_date < ?
+		ORDER BY a.due_date
+	`, userID, start.Format(DueDateLayout), end.Format(DueDateLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get required chores: %v", err)
+	}
+	for rows.Next() {
+		a := &Assignment{Chore: &Chore{IsRequired: true}}
+		if err := rows.Scan(&a.ChildID, &a.Chore.Description, &a.DueDate, &a.CompletedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan required chore: %v", err)
+		}
+		if a.CompletedAt.Valid && a.CompletedAt.Time.In(time.Local).Format(DueDateLayout) <= a.DueDate {
+			continue
+		}
+		if c, ok := byChild[a.ChildID]; ok {
+			c.Missed = append(c.Missed, a)
+		}
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT id, user_id, child_id, reward_id, description, points, redeemed_at
+		FROM reward_redemptions
+		WHERE user_id = ? AND redeemed_at >= ? AND redeemed_at < ?
+		ORDER BY redeemed_at
+	`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get redemptions: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		rr := &RewardRedemption{}
+		if err := rows.Scan(&rr.ID, &rr.UserID, &rr.ChildID, &rr.RewardID, &rr.Description, &rr.Points, &rr.RedeemedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan redemption: %v", err)
+		}
+		if c, ok := byChild[rr.ChildID]; ok {
+			c.Redemptions = append(c.Redemptions, rr)
+		}
+	}
+
+	return d, rows.Err()
+}
+
+// function to get the parents who want the weekly digest by email and
+// haven't been sent the digest for the week yet
+func GetDigestRecipients(db *sql.DB, week time.Time) ([]*NotificationPreferences, error) {
+	rows, err := db.Query(`
+		SELECT p.user_id, p.email
+		FROM notification_preferences p
+		WHERE p.notify_digest = 1 AND p.email_enabled = 1 AND p.email != ''
+			AND NOT EXISTS(SELECT 1 FROM digests_sent d WHERE d.user_id = p.user_id AND d.week_start = ?)
+	`, week.Format(DueDateLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get digest recipients: %v", err)
+	}
+	defer rows.Close()
+
+	var recipients []*NotificationPreferences
+	for rows.Next() {
+		p := &NotificationPreferences{NotifyDigest: true, EmailEnabled: true}
+		if err := rows.Scan(&p.UserID, &p.Email); err != nil {
+			return nil, fmt.Errorf("failed to scan digest recipient: %v", err)
+		}
+		recipients = append(recipients, p)
+	}
+	return recipients, rows.Err()
+}
+
+// function to record that a household's digest for a week has been sent
+func RecordDigestSent(db *sql.DB, userID int, week time.Time) error {
+	_, err := db.Exec("INSERT OR IGNORE INTO digests_sent (user_id, week_start) VALUES (?, ?)", userID, week.Format(DueDateLayout))
+	if err != nil {
+		return fmt.Errorf("failed to record digest: %v", err)
+	}
+	return nil
+}
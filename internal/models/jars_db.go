@@ -0,0 +1,431 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+)
+
+// the jars a child's points are split across in jar mode
+const (
+	JarSpend = "spend"
+	JarSave  = "save"
+	JarGive  = "give"
+)
+
+var JarNames = []string{JarSpend, JarSave, JarGive}
+
+// how often interest is paid on the save jar
+var InterestPeriods = []string{"week", "month"}
+
+var ErrJarTooLow = errors.New("not enough points in that jar")
+
+// function to get a household's jar settings, falling back to defaults
+func GetJarSettings(db DBTX, userID int) (*JarSettings, error) {
+	s := &JarSettings{UserID: userID, SpendPercent: 70, SavePercent: 20, GivePercent: 10, InterestPeriod: "month"}
+
+	err := db.QueryRow("SELECT enabled, spend_percent, save_percent, give_percent, interest_rate, interest_period FROM jar_settings WHERE user_id = ?", userID).
+		Scan(&s.Enabled, &s.SpendPercent, &s.SavePercent, &s.GivePercent, &s.InterestRate, &s.InterestPeriod)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get jar settings: %v", err)
+	}
+
+	return s, nil
+}
+
+// function to save a household's jar settings
+func (s *JarSettings) Save(db *sql.DB) error {
+	if s.SpendPercent < 0 || s.SavePercent < 0 || s.GivePercent < 0 || s.SpendPercent+s.SavePercent+s.GivePercent != 100 {
+		return fmt.Errorf("the jar split must add up to 100%%")
+	}
+	if s.InterestRate < 0 || s.InterestRate > 100 {
+		return fmt.Errorf("interest must be between 0 and 100%%")
+	}
+	if s.InterestPeriod != "week" && s.InterestPeriod != "month" {
+		return fmt.Errorf("invalid interest period: %s", s.InterestPeriod)
+	}
+
+	query := `
+		INSERT INTO jar_settings (user_id, enabled, spend_percent, save_percent, give_percent, interest_rate, interest_period)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			enabled = excluded.enabled,
+			spend_percent = excluded.spend_percent,
+			save_percent = excluded.save_percent,
+			give_percent = excluded.give_percent,
+			interest_rate = excluded.interest_rate,
+			interest_period = excluded.interest_period
+	`
+	_, err := db.Exec(query, s.UserID, s.Enabled, s.SpendPercent, s.SavePercent, s.GivePercent, s.InterestRate, s.InterestPeriod)
+	if err != nil {
+		return fmt.Errorf("failed to save jar settings: %v", err)
+	}
+	return nil
+}
+
+// function to split earned points across the jars. Rounding leftovers go to
+// the spend jar.
+func (s *JarSettings) Split(points int) (spend, save, give int) {
+	save = points * s.SavePercent / 100
+	give = points * s.GivePercent / 100
+	return points - save - give, save, give
+}
+
+// function to get when interest is next due after the last payment
+func (s *JarSettings) NextInterest(last time.Time) time.Time {
+	if s.InterestPeriod == "week" {
+		return last.AddDate(0, 0, 7)
+	}
+	return last.AddDate(0, 1, 0)
+}
+
+// function to get a child's jars, making sure they add up to the child's points
+func GetJars(db DBTX, child *Child) (*Jars, error) {
+	return loadJars(db, child, child.Points)
+}
+
+// function to load a child's jars and reconcile them with a balance. Points
+// that changed while jar mode was off are settled in the spend jar first.
+func loadJars(db DBTX, child *Child, balance int) (*Jars, error) {
+	j := &Jars{ChildID: child.ID, UserID: child.UserID}
+
+	err := db.QueryRow("SELECT spend, save, give, interest_paid_at FROM child_jars WHERE child_id = ?", child.ID).
+		Scan(&j.Spend, &j.Save, &j.Give, &j.InterestPaidAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get jars: %v", err)
+	}
+
+	diff := balance - j.Total()
+	if diff > 0 {
+		j.Spend += diff
+	} else if diff < 0 {
+		j.withdraw(-diff, JarSpend, JarSave, JarGive)
+	}
+
+	return j, nil
+}
+
+// function to save a child's jars
+func (j *Jars) SaveJars(db DBTX) error {
+	query := `
+		INSERT INTO child_jars (child_id, user_id, spend, save, give, interest_paid_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(child_id) DO UPDATE SET
+			spend = excluded.spend,
+			save = excluded.save,
+			give = excluded.give,
+			interest_paid_at = excluded.interest_paid_at
+	`
+	_, err := db.Exec(query, j.ChildID, j.UserID, j.Spend, j.Save, j.Give, j.InterestPaidAt)
+	if err != nil {
+		return fmt.Errorf("failed to save jars: %v", err)
+	}
+	return nil
+}
+
+// function to get the total points across all jars
+func (j *Jars) Total() int {
+	return j.Spend + j.Save + j.Give
+}
+
+// function to get the points in a jar by name
+func (j *Jars) Get(name string) int {
+	if jar := j.jar(name); jar != nil {
+		return *jar
+	}
+	return 0
+}
+
+func (j *Jars) jar(name string) *int {
+	switch name {
+	case JarSpend:
+		return &j.Spend
+	case JarSave:
+		return &j.Save
+	case JarGive:
+		return &j.Give
+	}
+	return nil
+}
+
+// function to take points out of the jars in order until the amount is covered
+func (j *Jars) withdraw(amount int, order ...string) {
+	for _, name := range order {
+		jar := j.jar(name)
+		take := min(amount, *jar)
+		*jar -= take
+		amount -= take
+	}
+}
+
+// function to cap the points a child can spend to their spend jar when jar
+// mode is on
+func LimitToSpendJar(db DBTX, child *Child, points int) (int, error) {
+	settings, err := GetJarSettings(db, child.UserID)
+	if err != nil {
+		return 0, err
+	}
+	if !settings.Enabled {
+		return points, nil
+	}
+
+	j, err := GetJars(db, child)
+	if err != nil {
+		return 0, err
+	}
+	return min(points, j.Spend), nil
+}
+
+// function to move a change in a child's points into or out of their jars.
+// Earnings are split by the household ratio, interest goes to the save jar
+// and refunds go back to the spend jar. Spending comes out of the spend jar,
+// and giving out of the give jar, before touching the others.
+func applyJars(db DBTX, child *Child, amount int, kind string) error {
+	settings, err := GetJarSettings(db, child.UserID)
+	if err != nil {
+		return err
+	}
+	if !settings.Enabled || amount == 0 {
+		return nil
+	}
+
+	j, err := loadJars(db, child, child.Points-amount)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case kind == PointsInterest:
+		j.Save += amount
+	case kind == PointsRefund:
+		j.Spend += amount
+	case amount > 0:
+		spend, save, give := settings.Split(amount)
+		j.Spend += spend
+		j.Save += save
+		j.Give += give
+	case kind == PointsGiven:
+		j.withdraw(-amount, JarGive, JarSpend, JarSave)
+	default:
+		j.withdraw(-amount, JarSpend, JarSave, JarGive)
+	}
+
+	return j.SaveJars(db)
+}
+
+// function to move points between a child's jars and log the transfer
+func TransferJars(db *sql.DB, child *Child, from, to string, amount int, note string) error {
+	if from == to || !slices.Contains(JarNames, from) || !slices.Contains(JarNames, to) {
+		return fmt.Errorf("invalid jars")
+	}
+	if amount <= 0 {
+		return fmt.Errorf("invalid number of points")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	j, err := GetJars(tx, child)
+	if err != nil {
+		return err
+	}
+	if j.Get(from) < amount {
+		return ErrJarTooLow
+	}
+	*j.jar(from) -= amount
+	*j.jar(to) += amount
+
+	err = j.SaveJars(tx)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO jar_transfers (user_id, child_id, from_jar, to_jar, amount, note) VALUES (?, ?, ?, ?, ?, ?)",
+		child.UserID, child.ID, from, to, amount, note)
+	if err != nil {
+		return fmt.Errorf("failed to log jar transfer: %v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit jar transfer: %v", err)
+	}
+	return nil
+}
+
+// function to get a child's most recent jar transfers, newest first
+func GetJarTransfers(db DBTX, childID int64, limit int) ([]*JarTransfer, error) {
+	query := `
+		SELECT id, user_id, child_id, from_jar, to_jar, amount, note, created_at
+		FROM jar_transfers
+		WHERE child_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`
+
+	rows, err := db.Query(query, childID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jar transfers: %v", err)
+	}
+	defer rows.Close()
+
+	var transfers []*JarTransfer
+	for rows.Next() {
+		t := &JarTransfer{}
+		err := rows.Scan(&t.ID, &t.UserID, &t.ChildID, &t.FromJar, &t.ToJar, &t.Amount, &t.Note, &t.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan jar transfer: %v", err)
+		}
+		transfers = append(transfers, t)
+	}
+
+	return transfers, rows.Err()
+}
+
+// function to give away points from a child's give jar, e.g. to a charity
+func GiveFromJar(db *sql.DB, child *Child, amount int, note string) error {
+	if amount <= 0 {
+		return fmt.Errorf("invalid number of points")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Check the jar against the current balance, not the caller's copy
+	current, err := GetChildByID(tx, child.ID)
+	if err != nil {
+		return err
+	}
+	*child = *current
+
+	j, err := GetJars(tx, child)
+	if err != nil {
+		return err
+	}
+	if j.Give < amount {
+		return ErrJarTooLow
+	}
+
+	child.Points -= amount
+	err = child.Save(tx)
+	if err != nil {
+		return err
+	}
+
+	description := "Given away"
+	if note != "" {
+		description += ": " + note
+	}
+	err = RecordPoints(tx, child, -amount, PointsGiven, description)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit gift: %v", err)
+	}
+	return nil
+}
+
+// function to pay interest on the save jar for every child whose interest is
+// due. The first run for a child only starts the clock. A child whose
+// interest can't be paid is logged and skipped so the others still get theirs.
+func PayInterest(db *sql.DB, now time.Time) error {
+	rows, err := db.Query(`
+		SELECT c.id FROM children c
+		JOIN jar_settings s ON s.user_id = c.user_id
+		WHERE s.enabled = 1 AND s.interest_rate > 0
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to get children for interest: %v", err)
+	}
+
+	var childIDs []int64
+	for rows.Next() {
+		var id int64
+		err := rows.Scan(&id)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan child: %v", err)
+		}
+		childIDs = append(childIDs, id)
+	}
+	rows.Close()
+
+	for _, id := range childIDs {
+		err := payChildInterest(db, id, now)
+		if err != nil {
+			log.Printf("PayInterest: child %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// function to pay a single child any interest that is due, one payment per
+// missed period so interest compounds
+func payChildInterest(db *sql.DB, childID int64, now time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	child, err := GetChildByID(tx, childID)
+	if err != nil {
+		return err
+	}
+
+	settings, err := GetJarSettings(tx, child.UserID)
+	if err != nil {
+		return err
+	}
+
+	j, err := GetJars(tx, child)
+	if err != nil {
+		return err
+	}
+
+	paidAt := now
+	if j.InterestPaidAt.Valid {
+		paidAt = j.InterestPaidAt.Time
+		for next := settings.NextInterest(paidAt); !next.After(now); next = settings.NextInterest(paidAt) {
+			interest := j.Save * settings.InterestRate / 100
+			if interest > 0 {
+				child.Points += interest
+				err = child.Save(tx)
+				if err != nil {
+					return err
+				}
+
+				err = RecordPoints(tx, child, interest, PointsInterest, fmt.Sprintf("%d%% interest on savings", settings.InterestRate))
+				if err != nil {
+					return err
+				}
+				j.Save += interest
+			}
+			paidAt = next
+		}
+	}
+
+	j.InterestPaidAt = sql.NullTime{Time: paidAt, Valid: true}
+	err = j.SaveJars(tx)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit interest: %v", err)
+	}
+	return nil
+}
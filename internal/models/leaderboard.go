@@ -0,0 +1,149 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// what children can be ranked on
+const (
+	MetricPoints = "points"
+	MetricChores = "chores"
+	MetricStreak = "streak"
+)
+
+var Metrics = []string{MetricPoints, MetricChores, MetricStreak}
+
+// labels of the metrics for display
+var MetricLabels = map[string]string{
+	MetricPoints: "Points earned",
+	MetricChores: "Chores completed",
+	MetricStreak: "Best streak",
+}
+
+// a child's place on the leaderboard for a period
+type Standing struct {
+	ChildID   int64
+	ChildName string
+	Rank      int
+	// Points earned from approved chores; bonuses and spending don't count
+	Points int
+	Chores int
+	// Streak is the longest run of days in a row with an approved chore
+	Streak int
+}
+
+// function to get the standing's value for a metric
+func (s *Standing) Score(metric string) int {
+	switch metric {
+	case MetricChores:
+		return s.Chores
+	case MetricStreak:
+		return s.Streak
+	}
+	return s.Points
+}
+
+// function to rank a household's children on chores approved between from
+// (inclusive) and to (exclusive). The standings come from the points
+// history rather than the spendable balance, so spending doesn't lose places.
+// Children with the same score share a rank.
+func GetStandings(db DBTX, userID int, from, to time.Time, metric string) ([]*Standing, error) {
+	rows, err := db.Query("SELECT id, name FROM children WHERE user_id = ? ORDER BY name", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get children: %v", err)
+	}
+
+	var standings []*Standing
+	byChild := make(map[int64]*Standing)
+	for rows.Next() {
+		s := &Standing{}
+		if err := rows.Scan(&s.ChildID, &s.ChildName); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan child: %v", err)
+		}
+		standings = append(standings, s)
+		byChild[s.ChildID] = s
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT child_id, amount, created_at
+		FROM point_transactions
+		WHERE user_id = ? AND kind = ? AND created_at >= ? AND created_at < ?
+	`, userID, PointsEarned, from.UTC().Format(timestampLayout), to.UTC().Format(timestampLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get completion history: %v", err)
+	}
+	defer rows.Close()
+
+	days := make(map[int64]map[time.Time]bool)
+	for rows.Next() {
+		var childID int64
+		var amount int
+		var createdAt time.Time
+		if err := rows.Scan(&childID, &amount, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan completion: %v", err)
+		}
+
+		s, ok := byChild[childID]
+		if !ok {
+			continue
+		}
+		s.Points += amount
+		s.Chores++
+
+		if days[childID] == nil {
+			days[childID] = make(map[time.Time]bool)
+		}
+		days[childID][startOfDay(createdAt)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over completions: %v", err)
+	}
+
+	for childID, set := range days {
+		byChild[childID].Streak = longestStreak(set)
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		return standings[i].Score(metric) > standings[j].Score(metric)
+	})
+	for i, s := range standings {
+		s.Rank = i + 1
+		if i > 0 && s.Score(metric) == standings[i-1].Score(metric) {
+			s.Rank = standings[i-1].Rank
+		}
+	}
+
+	return standings, nil
+}
+
+// function to get the leaders of a ranking; nobody leads without a score
+func Leaders(standings []*Standing, metric string) []*Standing {
+	var leaders []*Standing
+	for _, s := range standings {
+		if s.Rank == 1 && s.Score(metric) > 0 {
+			leaders = append(leaders, s)
+		}
+	}
+	return leaders
+}
+
+// function to find the longest run of consecutive days in a set
+func longestStreak(days map[time.Time]bool) int {
+	longest := 0
+	for day := range days {
+		// only count from the first day of each run
+		if days[day.AddDate(0, 0, -1)] {
+			continue
+		}
+		run := 1
+		for days[day.AddDate(0, 0, run)] {
+			run++
+		}
+		longest = max(longest, run)
+	}
+	return longest
+}
@@ -1,27 +1,71 @@
 package models
 
+import (
+	"database/sql"
+	"time"
+)
+
+// DBTX is implemented by both *sql.DB and *sql.Tx so model functions can be
+// used inside a transaction
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 type Chore struct {
-	ID          int64
-	UserID      int
-	Description string
-	Points      int
-	IsRequired  bool
-	IsCompleted bool
+	ID            int64
+	UserID        int
+	Description   string
+	Points        int
+	IsRequired    bool
+	IsCompleted   bool
+	Category      string
+	Tags          []string
+	Icon          string
+	PartialPoints bool
+	// Difficulty is 1 (easy) to 3 (hard); 0 is unset
+	Difficulty int
+	Minutes    int
+	// IsBounty marks the chore behind a bounty board job
+	IsBounty bool
 }
 
 type Child struct {
-	ID      int64
-	UserID  int
-	Name    string
-	Job     string
-	Points  int
-	Rewards string
+	ID         int64
+	UserID     int
+	Name       string
+	Job        string
+	Points     int
+	Rewards    string
+	Experience int
+	Birthdate  string
 }
 type Reward struct {
 	ID          int64
 	UserID      int
 	Description string
 	PointCost   int
+	// Stock is how many can be redeemed per StockPeriod across the household; 0 is unlimited
+	Stock       int
+	StockPeriod string
+	// Per-child limits; 0 disables each one
+	CooldownHours    int
+	ChildLimit       int
+	ChildLimitPeriod string
+	// Availability window; empty means any day or any time
+	AvailableDays  []int
+	AvailableFrom  string
+	AvailableUntil string
+	// RequiresChores locks the reward until required chores are approved,
+	// when the household store rule is set to selected rewards
+	RequiresChores bool
+	// Targeting; an empty ChildIDs list and zero ages show the reward to everyone
+	ChildIDs []int64
+	MinAge   int
+	MaxAge   int
+	// Prices overrides PointCost for individual children
+	Prices map[int64]int
 }
 
 type Assignment struct {
@@ -31,4 +75,310 @@ type Assignment struct {
 	ChildName   string
 	IsCompleted bool
 	Chore       *Chore
+	Steps       []*AssignmentStep
+	HasPhoto    bool
+	// DueDate is YYYY-MM-DD; empty means the chore can be done any time
+	DueDate    string
+	Recurrence string
+	// CompletedAt is set when the child finishes the chore and ApprovedAt
+	// when a parent rewards it; approved assignments are kept as history
+	CompletedAt   sql.NullTime
+	ApprovedAt    sql.NullTime
+	ApprovedBy    string
+	PointsAwarded int
+}
+
+// AssignmentReward is the outcome of approving an assignment
+type AssignmentReward struct {
+	// Child after being paid, including any quest bonuses
+	Child  *Child
+	Points int
+	// LevelUp is set when the chore or a quest bonus took the child up a level
+	LevelUp bool
+	// quests the approval completed
+	Quests []*QuestRun
+}
+
+// AssignmentHistory is one page of approved assignments, newest first
+type AssignmentHistory struct {
+	Assignments []*Assignment
+	Page        int
+	PageSize    int
+	Total       int
+}
+
+type ChoreStep struct {
+	ID          int64
+	ChoreID     int64
+	Position    int
+	Description string
+	IsRequired  bool
+}
+
+// AssignmentStep is a chore's checklist step with a child's progress on it
+type AssignmentStep struct {
+	StepID      int64
+	Description string
+	IsRequired  bool
+	IsChecked   bool
+}
+
+type Notification struct {
+	ID        int64
+	UserID    int
+	ChildID   int64
+	Kind      string
+	Title     string
+	Body      string
+	IsRead    bool
+	CreatedAt time.Time
+}
+
+type NotificationPreferences struct {
+	UserID          int
+	NotifyCompleted bool
+	NotifyRedeemed  bool
+	NotifyDigest    bool
+	InboxEnabled    bool
+	EmailEnabled    bool
+	Email           string
+	WebhookEnabled  bool
+	WebhookURL      string
+}
+
+type Webhook struct {
+	ID         int64
+	UserID     int
+	URL        string
+	Secret     string
+	EventTypes []string
+	CreatedAt  time.Time
+}
+
+type WebhookDelivery struct {
+	ID         int64
+	WebhookID  int64
+	UserID     int
+	URL        string
+	EventType  string
+	Payload    string
+	Attempt    int
+	StatusCode int
+	Error      string
+	Success    bool
+	CreatedAt  time.Time
+}
+
+type ChoreTemplate struct {
+	ID          int64
+	UserID      int
+	Description string
+	Points      int
+	IsRequired  bool
+	Category    string
+	MinAge      int
+	MaxAge      int
+	IsBuiltin   bool
+}
+
+type AssignmentPhoto struct {
+	ID            int64
+	AssignmentID  int64
+	UserID        int
+	Filename      string
+	ThumbFilename string
+	ContentType   string
+	CreatedAt     time.Time
+}
+
+// SavingsGoal is the reward a child has pinned to save towards. LockedPoints
+// are set aside for the goal and can't be spent on other rewards.
+type SavingsGoal struct {
+	ChildID      int64
+	RewardID     int64
+	LockedPoints int
+	Notified     bool
+	CreatedAt    time.Time
+	Reward       *Reward
+}
+
+type RewardRedemption struct {
+	ID          int64
+	UserID      int
+	ChildID     int64
+	RewardID    int64
+	Description string
+	Points      int
+	RedeemedAt  time.Time
+}
+
+type PointTransaction struct {
+	ID          int64
+	UserID      int
+	ChildID     int64
+	Amount      int
+	Balance     int
+	Kind        string
+	Category    string
+	Description string
+	CreatedAt   time.Time
+	ChildName   string
+}
+
+type AllowanceSettings struct {
+	UserID        int
+	Enabled       bool
+	PointsPerUnit int
+	Currency      string
+	MinCashOut    int
+}
+
+type CashOut struct {
+	ID          int64
+	UserID      int
+	ChildID     int64
+	ChildName   string
+	Points      int
+	AmountCents int
+	Status      string
+	Method      string
+	Note        string
+	RequestedAt time.Time
+	PaidAt      sql.NullTime
+}
+
+type CalendarFeed struct {
+	Token   string
+	UserID  int
+	ChildID int64
+}
+
+type ChorePricing struct {
+	UserID           int
+	PointsPerMinute  float64
+	MediumMultiplier float64
+	HardMultiplier   float64
+}
+
+type StoreRules struct {
+	UserID             int
+	RequiredChoresLock string
+}
+
+type SwapRules struct {
+	UserID int
+	// RequireApproval holds accepted swaps until a parent approves them
+	RequireApproval bool
+}
+
+type JarSettings struct {
+	UserID         int
+	Enabled        bool
+	SpendPercent   int
+	SavePercent    int
+	GivePercent    int
+	InterestRate   int
+	InterestPeriod string
+}
+
+type Jars struct {
+	ChildID        int64
+	UserID         int
+	Spend          int
+	Save           int
+	Give           int
+	InterestPaidAt sql.NullTime
+}
+
+type JarTransfer struct {
+	ID        int64
+	UserID    int
+	ChildID   int64
+	FromJar   string
+	ToJar     string
+	Amount    int
+	Note      string
+	CreatedAt time.Time
+}
+
+type Competition struct {
+	ID     int64
+	UserID int
+	Name   string
+	// Metric is what children are ranked on: points, chores or streak
+	Metric string
+	// First and last day of the competition, both inclusive
+	StartDate     string
+	EndDate       string
+	PrizeRewardID int64
+	PrizeName     string
+	IsClosed      bool
+	Winners       string
+}
+
+type Quest struct {
+	ID     int64
+	UserID int
+	Name   string
+	Story  string
+	// Deadline is the last day to finish the quest; empty means no deadline
+	Deadline        string
+	BonusPoints     int
+	BonusRewardID   int64
+	BonusRewardName string
+	Chores          []*Chore
+}
+
+// QuestRun is a child's attempt at a quest
+type QuestRun struct {
+	ID          int64
+	UserID      int
+	Quest       *Quest
+	ChildID     int64
+	ChildName   string
+	StartedAt   time.Time
+	CompletedAt sql.NullTime
+	// chores approved since the quest was started
+	Approved map[int64]bool
+}
+
+// Bounty is a one-off job on the bounty board. The job is backed by a chore
+// so claims go through the usual completion and approval flow.
+type Bounty struct {
+	ID        int64
+	UserID    int
+	Chore     *Chore
+	ExpiresAt time.Time
+	// MaxClaims is how many children can take the job; 0 is unlimited
+	MaxClaims int
+	IsExpired bool
+	CreatedAt time.Time
+	Claims    []*BountyClaim
+}
+
+type BountyClaim struct {
+	ChildID   int64
+	ChildName string
+	ClaimedAt time.Time
+}
+
+// ChoreSwap is one child's offer to hand an assignment to a sibling, in return
+// for one of the sibling's assignments and/or points
+type ChoreSwap struct {
+	ID                    int64
+	UserID                int
+	FromChildID           int64
+	FromChildName         string
+	ToChildID             int64
+	ToChildName           string
+	OfferedAssignmentID   int64
+	OfferedChore          string
+	RequestedAssignmentID int64
+	RequestedChore        string
+	// Points the proposer pays on top; 0 is none
+	Points     int
+	Note       string
+	Status     string
+	CreatedAt  time.Time
+	ResolvedAt sql.NullTime
 }
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// function to save a notification to the inbox
+func (n *Notification) Save(db *sql.DB) error {
+	if n.ID == 0 {
+		result, err := db.Exec("INSERT INTO notifications (user_id, child_id, kind, title, body, is_read) VALUES (?, ?, ?, ?, ?, ?)",
+			n.UserID, n.ChildID, n.Kind, n.Title, n.Body, n.IsRead)
+		if err != nil {
+			return err
+		}
+
+		n.ID, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+	} else {
+		_, err := db.Exec("UPDATE notifications SET is_read = ? WHERE id = ? AND user_id = ?", n.IsRead, n.ID, n.UserID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// function to get a notification by ID from the database
+func GetNotificationByID(db *sql.DB, id int64) (*Notification, error) {
+	query := "SELECT id, user_id, child_id, kind, title, body, is_read, created_at FROM notifications WHERE id = ?"
+	row := db.QueryRow(query, id)
+
+	n := &Notification{}
+	err := row.Scan(&n.ID, &n.UserID, &n.ChildID, &n.Kind, &n.Title, &n.Body, &n.IsRead, &n.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no notification found with ID %d", id)
+		}
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// function to get the most recent notifications for a user, newest first
+func GetNotificationsByUserID(db *sql.DB, userID int, limit int) ([]*Notification, error) {
+	query := `
+		SELECT id, user_id, child_id, kind, title, body, is_read, created_at
+		FROM notifications
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`
+
+	rows, err := db.Query(query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %v", err)
+	}
+	defer rows.Close()
+
+	var notifications []*Notification
+	for rows.Next() {
+		n := &Notification{}
+		err := rows.Scan(&n.ID, &n.UserID, &n.ChildID, &n.Kind, &n.Title, &n.Body, &n.IsRead, &n.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %v", err)
+		}
+		notifications = append(notifications, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %v", err)
+	}
+
+	return notifications, nil
+}
+
+// function to count unread notifications for a user
+func CountUnreadNotifications(db *sql.DB, userID int) (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM notifications WHERE user_id = ? AND is_read = 0", userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %v", err)
+	}
+	return count, nil
+}
+
+// function to mark every notification for a user as read
+func MarkAllNotificationsRead(db *sql.DB, userID int) error {
+	_, err := db.Exec("UPDATE notifications SET is_read = 1 WHERE user_id = ? AND is_read = 0", userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark notifications as read: %v", err)
+	}
+	return nil
+}
+
+// function to get a user's notification preferences; users who have never
+// saved preferences get the defaults with their account email filled in
+func GetNotificationPreferences(db *sql.DB, userID int) (*NotificationPreferences, error) {
+	query := `
+		SELECT user_id, notify_completed, notify_redeemed, notify_digest, inbox_enabled, email_enabled, email, webhook_enabled, webhook_url
+		FROM notification_preferences
+		WHERE user_id = ?
+	`
+
+	prefs := &NotificationPreferences{}
+	err := db.QueryRow(query, userID).Scan(
+		&prefs.UserID,
+		&prefs.NotifyCompleted,
+		&prefs.NotifyRedeemed,
+		&prefs.NotifyDigest,
+		&prefs.InboxEnabled,
+		&prefs.EmailEnabled,
+		&prefs.Email,
+		&prefs.WebhookEnabled,
+		&prefs.WebhookURL,
+	)
+	if err == sql.ErrNoRows {
+		prefs = &NotificationPreferences{
+			UserID:          userID,
+			NotifyCompleted: true,
+			NotifyRedeemed:  true,
+			NotifyDigest:    true,
+			InboxEnabled:    true,
+		}
+		err = db.QueryRow("SELECT email FROM users WHERE id = ?", userID).Scan(&prefs.Email)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to get user email: %v", err)
+		}
+		return prefs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %v", err)
+	}
+
+	return prefs, nil
+}
+
+// function to save a user's notification preferences
+func (p *NotificationPreferences) Save(db *sql.DB) error {
+	_, err := db.Exec(`
+		INSERT INTO notification_preferences (user_id, notify_completed, notify_redeemed, notify_digest, inbox_enabled, email_enabled, email, webhook_enabled, webhook_url)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			notify_completed = excluded.notify_completed,
+			notify_redeemed = excluded.notify_redeemed,
+			notify_digest = excluded.notify_digest,
+			inbox_enabled = excluded.inbox_enabled,
+			email_enabled = excluded.email_enabled,
+			email = excluded.email,
+			webhook_enabled = excluded.webhook_enabled,
+			webhook_url = excluded.webhook_url
+	`, p.UserID, p.NotifyCompleted, p.NotifyRedeemed, p.NotifyDigest, p.InboxEnabled, p.EmailEnabled, p.Email, p.WebhookEnabled, p.WebhookURL)
+	return err
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// function to save an assignment photo, replacing any earlier photo for the
+// same assignment
+func (p *AssignmentPhoto) Save(db *sql.DB) error {
+	query := `
+		INSERT INTO assignment_photos (assignment_id, user_id, filename, thumb_filename, content_type)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(assignment_id) DO UPDATE SET
+			filename = excluded.filename,
+			thumb_filename = excluded.thumb_filename,
+			content_type = excluded.content_type,
+			created_at = CURRENT_TIMESTAMP
+	`
+	_, err := db.Exec(query, p.AssignmentID, p.UserID, p.Filename, p.ThumbFilename, p.ContentType)
+	if err != nil {
+		return fmt.Errorf("failed to save assignment photo: %v", err)
+	}
+
+	return db.QueryRow("SELECT id, created_at FROM assignment_photos WHERE assignment_id = ?", p.AssignmentID).
+		Scan(&p.ID, &p.CreatedAt)
+}
+
+// function to get the photo attached to an assignment
+func GetAssignmentPhoto(db *sql.DB, assignmentID int64) (*AssignmentPhoto, error) {
+	query := "SELECT id, assignment_id, user_id, filename, thumb_filename, content_type, created_at FROM assignment_photos WHERE assignment_id = ?"
+	row := db.QueryRow(query, assignmentID)
+
+	p := &AssignmentPhoto{}
+	err := row.Scan(&p.ID, &p.AssignmentID, &p.UserID, &p.Filename, &p.ThumbFilename, &p.ContentType, &p.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no photo found for assignment %d", assignmentID)
+		}
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// function to get photos uploaded before the given time
+func GetAssignmentPhotosBefore(db *sql.DB, before time.Time) ([]*AssignmentPhoto, error) {
+	query := `
+		SELECT id, assignment_id, user_id, filename, thumb_filename, content_type, created_at
+		FROM assignment_photos
+		WHERE created_at < ?
+	`
+
+	rows, err := db.Query(query, before.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment photos: %v", err)
+	}
+	defer rows.Close()
+
+	var photos []*AssignmentPhoto
+	for rows.Next() {
+		p := &AssignmentPhoto{}
+		err := rows.Scan(&p.ID, &p.AssignmentID, &p.UserID, &p.Filename, &p.ThumbFilename, &p.ContentType, &p.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan assignment photo: %v", err)
+		}
+		photos = append(photos, p)
+	}
+
+	return photos, rows.Err()
+}
+
+// function to get photos whose assignment has been approved or no longer
+// exists; approved assignments are kept for history, so a missing row alone
+// doesn't mean the photo is done with
+func GetOrphanedAssignmentPhotos(db *sql.DB) ([]*AssignmentPhoto, error) {
+	query := `
+		SELECT p.id, p.assignment_id, p.user_id, p.filename, p.thumb_filename, p.content_type, p.created_at
+		FROM assignment_photos p
+		LEFT JOIN assignments a ON a.id = p.assignment_id
+		WHERE a.id IS NULL OR a.approved_at IS NOT NULL
+	`
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orphaned photos: %v", err)
+	}
+	defer rows.Close()
+
+	var photos []*AssignmentPhoto
+	for rows.Next() {
+		p := &AssignmentPhoto{}
+		err := rows.Scan(&p.ID, &p.AssignmentID, &p.UserID, &p.Filename, &p.ThumbFilename, &p.ContentType, &p.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan assignment photo: %v", err)
+		}
+		photos = append(photos, p)
+	}
+
+	return photos, rows.Err()
+}
+
+// function to delete an assignment photo record
+func DeleteAssignmentPhoto(db *sql.DB, id int64) error {
+	_, err := db.Exec("DELETE FROM assignment_photos WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete assignment photo: %v", err)
+	}
+	return nil
+}
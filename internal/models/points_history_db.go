@@ -0,0 +1,213 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// kinds of point transactions
+const (
+	PointsEarned   = "earned"
+	PointsSpent    = "spent"
+	PointsCashOut  = "cash_out"
+	PointsRefund   = "refund"
+	PointsAdjusted = "adjusted"
+	PointsInterest = "interest"
+	PointsGiven    = "given"
+	PointsBonus    = "bonus"
+	PointsPenalty  = "penalty"
+	PointsQuest    = "quest"
+	PointsSwap     = "swap"
+)
+
+// categories a parent can pick for a bonus or penalty
+var AdjustmentCategories = []string{"kindness", "helpfulness", "attitude", "late", "other"}
+
+// function to record a change to a child's points. Call it after the new
+// balance has been saved so the entry shows the balance after the change.
+// In jar mode the change is also moved into or out of the child's jars.
+func RecordPoints(db DBTX, child *Child, amount int, kind, description string) error {
+	return recordPoints(db, child, amount, kind, "", description)
+}
+
+func recordPoints(db DBTX, child *Child, amount int, kind, category, description string) error {
+	_, err := db.Exec("INSERT INTO point_transactions (user_id, child_id, amount, balance, kind, category, description) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		child.UserID, child.ID, amount, child.Points, kind, category, description)
+	if err != nil {
+		return fmt.Errorf("failed to record points: %v", err)
+	}
+	return applyJars(db, child, amount, kind)
+}
+
+// function to get a child's most recent point transactions, newest first
+func GetPointHistory(db DBTX, childID int64, limit int) ([]*PointTransaction, error) {
+	query := `
+		SELECT id, user_id, child_id, amount, balance, kind, category, description, created_at
+		FROM point_transactions
+		WHERE child_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`
+
+	rows, err := db.Query(query, childID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get point history: %v", err)
+	}
+	defer rows.Close()
+
+	var history []*PointTransaction
+	for rows.Next() {
+		t := &PointTransaction{}
+		err := rows.Scan(&t.ID, &t.UserID, &t.ChildID, &t.Amount, &t.Balance, &t.Kind, &t.Category, &t.Description, &t.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan point transaction: %v", err)
+		}
+		history = append(history, t)
+	}
+
+	return history, rows.Err()
+}
+
+// function to get the icon shown next to a transaction in the history
+func (t *PointTransaction) Icon() string {
+	switch t.Kind {
+	case PointsEarned:
+		return "✅"
+	case PointsSpent:
+		return "🎁"
+	case PointsCashOut:
+		return "💵"
+	case PointsRefund:
+		return "↩️"
+	case PointsInterest:
+		return "🏦"
+	case PointsGiven:
+		return "💝"
+	case PointsBonus:
+		return "⭐"
+	case PointsPenalty:
+		return "⚠️"
+	case PointsQuest:
+		return "🗺️"
+	case PointsSwap:
+		return "🔁"
+	}
+	return "✏️"
+}
+
+// function for a parent to give a bonus (positive amount) or apply a penalty
+// (negative amount). A penalty can't take a child below zero points.
+func AdjustPoints(db *sql.DB, child *Child, amount int, category, reason string) error {
+	if amount == 0 {
+		return fmt.Errorf("points must not be zero")
+	}
+	if reason == "" {
+		return fmt.Errorf("a reason is required")
+	}
+
+	kind := PointsBonus
+	if amount < 0 {
+		kind = PointsPenalty
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Work from the current balance so a reward or redemption that landed
+	// since the child was loaded isn't overwritten
+	current, err := GetChildByID(tx, child.ID)
+	if err != nil {
+		return err
+	}
+	*child = *current
+
+	if child.Points+amount < 0 {
+		return fmt.Errorf("%s only has %d points", child.Name, child.Points)
+	}
+
+	child.Points += amount
+	err = child.Save(tx)
+	if err != nil {
+		return err
+	}
+
+	err = recordPoints(tx, child, amount, kind, category, reason)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit adjustment: %v", err)
+	}
+	return nil
+}
+
+// function to get a household's bonuses and penalties since a point in time,
+// newest first
+func GetAdjustments(db DBTX, userID int, since time.Time) ([]*PointTransaction, error) {
+	query := `
+		SELECT t.id, t.user_id, t.child_id, c.name, t.amount, t.balance, t.kind, t.category, t.description, t.created_at
+		FROM point_transactions t
+		JOIN children c ON c.id = t.child_id
+		WHERE t.user_id = ? AND t.kind IN (?, ?) AND t.created_at >= ?
+		ORDER BY t.created_at DESC, t.id DESC
+	`
+
+	rows, err := db.Query(query, userID, PointsBonus, PointsPenalty, since.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adjustments: %v", err)
+	}
+	defer rows.Close()
+
+	var adjustments []*PointTransaction
+	for rows.Next() {
+		t := &PointTransaction{}
+		err := rows.Scan(&t.ID, &t.UserID, &t.ChildID, &t.ChildName, &t.Amount, &t.Balance, &t.Kind, &t.Category, &t.Description, &t.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan adjustment: %v", err)
+		}
+		adjustments = append(adjustments, t)
+	}
+
+	return adjustments, rows.Err()
+}
+
+// totals of a child's bonuses and penalties for reports
+type AdjustmentTotals struct {
+	ChildID   int64
+	ChildName string
+	Bonus     int
+	Penalty   int
+	Count     int
+}
+
+// function to total bonuses and penalties per child, ordered by name
+func TotalAdjustments(adjustments []*PointTransaction) []*AdjustmentTotals {
+	byChild := make(map[int64]*AdjustmentTotals)
+	var totals []*AdjustmentTotals
+	for _, t := range adjustments {
+		total, ok := byChild[t.ChildID]
+		if !ok {
+			total = &AdjustmentTotals{ChildID: t.ChildID, ChildName: t.ChildName}
+			byChild[t.ChildID] = total
+			totals = append(totals, total)
+		}
+		if t.Amount > 0 {
+			total.Bonus += t.Amount
+		} else {
+			total.Penalty -= t.Amount
+		}
+		total.Count++
+	}
+
+	sort.Slice(totals, func(i, j int) bool {
+		return totals[i].ChildName < totals[j].ChildName
+	})
+	return totals
+}
@@ -0,0 +1,464 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// function to save a quest and its member chores
+func (q *Quest) Save(db *sql.DB) error {
+	if strings.TrimSpace(q.Name) == "" {
+		return fmt.Errorf("a name is required")
+	}
+	if len(q.Chores) == 0 {
+		return fmt.Errorf("a quest needs at least one chore")
+	}
+	if q.BonusPoints < 0 {
+		return fmt.Errorf("bonus points must not be negative")
+	}
+	if q.Deadline != "" {
+		if _, err := time.Parse(DueDateLayout, q.Deadline); err != nil {
+			return fmt.Errorf("invalid deadline: %s", q.Deadline)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if q.ID == 0 {
+		result, err := tx.Exec("INSERT INTO quests (user_id, name, story, deadline, bonus_points, bonus_reward_id) VALUES (?, ?, ?, ?, ?, ?)",
+			q.UserID, q.Name, q.Story, q.Deadline, q.BonusPoints, q.BonusRewardID)
+		if err != nil {
+			return fmt.Errorf("failed to save quest: %v", err)
+		}
+
+		q.ID, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+	} else {
+		_, err := tx.Exec("UPDATE quests SET name = ?, story = ?, deadline = ?, bonus_points = ?, bonus_reward_id = ? WHERE id = ? AND user_id = ?",
+			q.Name, q.Story, q.Deadline, q.BonusPoints, q.BonusRewardID, q.ID, q.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to update quest: %v", err)
+		}
+
+		_, err = tx.Exec("DELETE FROM quest_chores WHERE quest_id = ?", q.ID)
+		if err != nil {
+			return fmt.Errorf("failed to clear quest chores: %v", err)
+		}
+	}
+
+	for _, chore := range q.Chores {
+		_, err := tx.Exec("INSERT INTO quest_chores (quest_id, chore_id) VALUES (?, ?)", q.ID, chore.ID)
+		if err != nil {
+			return fmt.Errorf("failed to save quest chore: %v", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit quest: %v", err)
+	}
+	return nil
+}
+
+// function to load the member chores of quests
+func loadQuestChores(db DBTX, quests []*Quest) error {
+	for _, q := range quests {
+		rows, err := db.Query("SELECT "+choreColumns+" FROM chores WHERE id IN (SELECT chore_id FROM quest_chores WHERE quest_id = ?) ORDER BY description", q.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get quest chores: %v", err)
+		}
+
+		q.Chores = nil
+		for rows.Next() {
+			chore, err := scanChore(rows)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan quest chore: %v", err)
+			}
+			q.Chores = append(q.Chores, chore)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("error iterating over quest chores: %v", err)
+		}
+	}
+	return nil
+}
+
+const questColumns = `q.id, q.user_id, q.name, q.story, q.deadline, q.bonus_points, q.bonus_reward_id, COALESCE(r.description, '')`
+
+func scanQuest(row interface{ Scan(...interface{}) error }) (*Quest, error) {
+	q := &Quest{}
+	err := row.Scan(&q.ID, &q.UserID, &q.Name, &q.Story, &q.Deadline, &q.BonusPoints, &q.BonusRewardID, &q.BonusRewardName)
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// function to get a quest and its chores by ID
+func GetQuestByID(db DBTX, id int64) (*Quest, error) {
+	row := db.QueryRow("SELECT "+questColumns+" FROM quests q LEFT JOIN rewards r ON r.id = q.bonus_reward_id WHERE q.id = ?", id)
+	q, err := scanQuest(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no quest found with ID %d", id)
+		}
+		return nil, fmt.Errorf("failed to get quest: %v", err)
+	}
+
+	err = loadQuestChores(db, []*Quest{q})
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// function to get a household's quests and their chores
+func GetQuestsByUserID(db DBTX, userID int) ([]*Quest, error) {
+	rows, err := db.Query("SELECT "+questColumns+" FROM quests q LEFT JOIN rewards r ON r.id = q.bonus_reward_id WHERE q.user_id = ? ORDER BY q.name", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quests: %v", err)
+	}
+
+	var quests []*Quest
+	for rows.Next() {
+		q, err := scanQuest(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan quest: %v", err)
+		}
+		quests = append(quests, q)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error iterating over quests: %v", err)
+	}
+
+	err = loadQuestChores(db, quests)
+	if err != nil {
+		return nil, err
+	}
+	return quests, nil
+}
+
+// function to delete a quest along with every child's progress on it
+func DeleteQuest(db *sql.DB, id int64) error {
+	statements := []string{
+		"DELETE FROM quest_run_chores WHERE run_id IN (SELECT id FROM quest_runs WHERE quest_id = ?)",
+		"DELETE FROM quest_runs WHERE quest_id = ?",
+		"DELETE FROM quest_chores WHERE quest_id = ?",
+		"DELETE FROM quests WHERE id = ?",
+	}
+	for _, statement := range statements {
+		_, err := db.Exec(statement, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete quest: %v", err)
+		}
+	}
+	return nil
+}
+
+// function to check whether the quest's deadline has passed
+func (q *Quest) Expired(now time.Time) bool {
+	if q.Deadline == "" {
+		return false
+	}
+	deadline, err := time.ParseInLocation(DueDateLayout, q.Deadline, time.Local)
+	return err == nil && deadline.Before(startOfDay(now))
+}
+
+// function to describe the quest's bonus for display
+func (q *Quest) BonusSummary() string {
+	var parts []string
+	if q.BonusPoints > 0 {
+		parts = append(parts, fmt.Sprintf("%d bonus points", q.BonusPoints))
+	}
+	if q.BonusRewardName != "" {
+		parts = append(parts, q.BonusRewardName)
+	}
+	return strings.Join(parts, " and ")
+}
+
+// function to start a quest for a child. Every member chore the child
+// doesn't already have is assigned, due on the quest's deadline.
+func StartQuest(db *sql.DB, quest *Quest, child *Child, now time.Time) (*QuestRun, error) {
+	if quest.Expired(now) {
+		return nil, fmt.Errorf("the deadline for %s has passed", quest.Name)
+	}
+
+	var running bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM quest_runs WHERE quest_id = ? AND child_id = ? AND completed_at IS NULL)", quest.ID, child.ID).Scan(&running)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check quest progress: %v", err)
+	}
+	if running {
+		return nil, fmt.Errorf("%s is already on %s", child.Name, quest.Name)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO quest_runs (user_id, quest_id, child_id, started_at) VALUES (?, ?, ?, ?)",
+		child.UserID, quest.ID, child.ID, now.UTC().Format(timestampLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start quest: %v", err)
+	}
+
+	run := &QuestRun{UserID: child.UserID, Quest: quest, ChildID: child.ID, ChildName: child.Name, StartedAt: now, Approved: map[int64]bool{}}
+	run.ID, err = result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, chore := range quest.Chores {
+		var assigned bool
+		err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM assignments WHERE child_id = ? AND chore_id = ? AND approved_at IS NULL)", child.ID, chore.ID).Scan(&assigned)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if assignment exists: %v", err)
+		}
+		if assigned {
+			continue
+		}
+
+		err = ScheduleChore(tx, child.ID, chore.ID, quest.Deadline, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit quest: %v", err)
+	}
+	return run, nil
+}
+
+// function to load quest runs matching a condition, with their quests and
+// approved chores
+func getQuestRuns(db DBTX, where string, args ...interface{}) ([]*QuestRun, error) {
+	rows, err := db.Query(`
+		SELECT qr.id, qr.user_id, qr.quest_id, qr.child_id, c.name, qr.started_at, qr.completed_at
+		FROM quest_runs qr
+		JOIN children c ON c.id = qr.child_id
+		WHERE `+where+`
+		ORDER BY qr.completed_at IS NOT NULL, qr.started_at DESC, qr.id DESC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quest runs: %v", err)
+	}
+
+	var runs []*QuestRun
+	var questIDs []int64
+	for rows.Next() {
+		run := &QuestRun{Approved: map[int64]bool{}}
+		var questID int64
+		err := rows.Scan(&run.ID, &run.UserID, &questID, &run.ChildID, &run.ChildName, &run.StartedAt, &run.CompletedAt)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan quest run: %v", err)
+		}
+		runs = append(runs, run)
+		questIDs = append(questIDs, questID)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error iterating over quest runs: %v", err)
+	}
+
+	quests := make(map[int64]*Quest)
+	for i, run := range runs {
+		quest, ok := quests[questIDs[i]]
+		if !ok {
+			quest, err = GetQuestByID(db, questIDs[i])
+			if err != nil {
+				return nil, err
+			}
+			quests[quest.ID] = quest
+		}
+		run.Quest = quest
+
+		choreRows, err := db.Query("SELECT chore_id FROM quest_run_chores WHERE run_id = ?", run.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get quest progress: %v", err)
+		}
+		for choreRows.Next() {
+			var choreID int64
+			if err := choreRows.Scan(&choreID); err != nil {
+				choreRows.Close()
+				return nil, fmt.Errorf("failed to scan quest progress: %v", err)
+			}
+			run.Approved[choreID] = true
+		}
+		err = choreRows.Err()
+		choreRows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating over quest progress: %v", err)
+		}
+	}
+
+	return runs, nil
+}
+
+// function to get a child's quests, unfinished ones first
+func GetQuestRunsByChild(db DBTX, childID int64) ([]*QuestRun, error) {
+	return getQuestRuns(db, "qr.child_id = ?", childID)
+}
+
+// function to get every child's quests in a household, unfinished ones first
+func GetQuestRunsByUserID(db DBTX, userID int) ([]*QuestRun, error) {
+	return getQuestRuns(db, "qr.user_id = ?", userID)
+}
+
+// function to get a quest run by ID
+func GetQuestRunByID(db DBTX, id int64) (*QuestRun, error) {
+	runs, err := getQuestRuns(db, "qr.id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("no quest run found with ID %d", id)
+	}
+	return runs[0], nil
+}
+
+// function to abandon a child's quest; assigned chores are left in place
+func DeleteQuestRun(db *sql.DB, id int64) error {
+	_, err := db.Exec("DELETE FROM quest_run_chores WHERE run_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete quest progress: %v", err)
+	}
+	_, err = db.Exec("DELETE FROM quest_runs WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete quest run: %v", err)
+	}
+	return nil
+}
+
+// function to count the quest's chores that have been approved
+func (run *QuestRun) Progress() int {
+	done := 0
+	for _, chore := range run.Quest.Chores {
+		if run.Approved[chore.ID] {
+			done++
+		}
+	}
+	return done
+}
+
+// function to get the progress as a whole percentage
+func (run *QuestRun) Percent() int {
+	if len(run.Quest.Chores) == 0 {
+		return 0
+	}
+	return run.Progress() * 100 / len(run.Quest.Chores)
+}
+
+// function to check whether the quest run has been completed
+func (run *QuestRun) IsComplete() bool {
+	return run.CompletedAt.Valid
+}
+
+// function to check whether the deadline passed before the quest was completed
+func (run *QuestRun) IsExpired() bool {
+	return !run.IsComplete() && run.Quest.Expired(time.Now())
+}
+
+// function to record that a chore was approved for a child, completing any of
+// the child's quests that were waiting on it. The quest bonus is only granted
+// when the quest is completed by its deadline. Returns the completed runs and
+// whether a bonus took the child up a level.
+func AdvanceQuests(db DBTX, child *Child, choreID int64, now time.Time) ([]*QuestRun, bool, error) {
+	runs, err := getQuestRuns(db, "qr.child_id = ? AND qr.completed_at IS NULL", child.ID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var completed []*QuestRun
+	levelUp := false
+	for _, run := range runs {
+		if run.Approved[choreID] || run.Quest.Expired(now) || !run.Quest.HasChore(choreID) {
+			continue
+		}
+
+		_, err := db.Exec("INSERT INTO quest_run_chores (run_id, chore_id, approved_at) VALUES (?, ?, ?)",
+			run.ID, choreID, now.UTC().Format(timestampLayout))
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to record quest progress: %v", err)
+		}
+		run.Approved[choreID] = true
+
+		if run.Progress() < len(run.Quest.Chores) {
+			continue
+		}
+
+		leveled, err := completeQuest(db, child, run, now)
+		if err != nil {
+			return nil, false, err
+		}
+		levelUp = levelUp || leveled
+		completed = append(completed, run)
+	}
+
+	return completed, levelUp, nil
+}
+
+// function to check whether a chore is part of the quest
+func (q *Quest) HasChore(choreID int64) bool {
+	for _, chore := range q.Chores {
+		if chore.ID == choreID {
+			return true
+		}
+	}
+	return false
+}
+
+// function to mark a quest run complete and grant its bonus points and
+// reward; returns true when the bonus takes the child up a level
+func completeQuest(db DBTX, child *Child, run *QuestRun, now time.Time) (bool, error) {
+	levelUp := false
+	if run.Quest.BonusPoints > 0 {
+		levelUp = child.Earn(run.Quest.BonusPoints)
+		err := child.Save(db)
+		if err != nil {
+			return false, err
+		}
+
+		err = RecordPoints(db, child, run.Quest.BonusPoints, PointsQuest, "Quest complete: "+run.Quest.Name)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if run.Quest.BonusRewardID != 0 {
+		reward, err := GetRewardByID(db, run.Quest.BonusRewardID)
+		// the reward may have been deleted since the quest was set up
+		if err == nil && reward.UserID == child.UserID {
+			err = grantReward(db, child, reward, reward.Description+" (quest: "+run.Quest.Name+")", now)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+
+	_, err := db.Exec("UPDATE quest_runs SET completed_at = ? WHERE id = ?", now.UTC().Format(timestampLayout), run.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to complete quest: %v", err)
+	}
+
+	run.CompletedAt = sql.NullTime{Time: now, Valid: true}
+	return levelUp, nil
+}
@@ -3,14 +3,39 @@ package models
 import (
 	"database/sql"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
+const rewardColumns = `id, user_id, description, point_cost, stock, stock_period, cooldown_hours,
+	child_limit, child_limit_period, available_days, available_from, available_until, min_age, max_age, requires_chores`
+
+// function to scan a reward row selected with rewardColumns
+func scanReward(row interface{ Scan(...interface{}) error }) (*Reward, error) {
+	reward := &Reward{}
+	var days string
+	err := row.Scan(&reward.ID, &reward.UserID, &reward.Description, &reward.PointCost,
+		&reward.Stock, &reward.StockPeriod, &reward.CooldownHours,
+		&reward.ChildLimit, &reward.ChildLimitPeriod, &days, &reward.AvailableFrom, &reward.AvailableUntil,
+		&reward.MinAge, &reward.MaxAge, &reward.RequiresChores)
+	if err != nil {
+		return nil, err
+	}
+	reward.AvailableDays = parseDays(days)
+	return reward, nil
+}
+
 // function to save a reward to the database
 func (r *Reward) Save(db *sql.DB) error {
+	days := joinDays(r.AvailableDays)
+
 	// If the reward is new, insert it
 	if r.ID == 0 {
-		result, err := db.Exec("INSERT INTO rewards (user_id, description, point_cost) VALUES (?, ?, ?)",
-			r.UserID, r.Description, r.PointCost)
+		result, err := db.Exec(`INSERT INTO rewards (user_id, description, point_cost, stock, stock_period, cooldown_hours,
+			child_limit, child_limit_period, available_days, available_from, available_until, min_age, max_age, requires_chores)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			r.UserID, r.Description, r.PointCost, r.Stock, r.StockPeriod, r.CooldownHours,
+			r.ChildLimit, r.ChildLimitPeriod, days, r.AvailableFrom, r.AvailableUntil, r.MinAge, r.MaxAge, r.RequiresChores)
 		if err != nil {
 			return err
 		}
@@ -21,22 +46,24 @@ func (r *Reward) Save(db *sql.DB) error {
 		}
 	} else {
 		// If the reward is not new, update it
-		_, err := db.Exec("UPDATE rewards SET description = ?, point_cost = ? WHERE id = ? AND user_id = ?",
-			r.Description, r.PointCost, r.ID, r.UserID)
+		_, err := db.Exec(`UPDATE rewards SET description = ?, point_cost = ?, stock = ?, stock_period = ?, cooldown_hours = ?,
+			child_limit = ?, child_limit_period = ?, available_days = ?, available_from = ?, available_until = ?,
+			min_age = ?, max_age = ?, requires_chores = ?
+			WHERE id = ? AND user_id = ?`,
+			r.Description, r.PointCost, r.Stock, r.StockPeriod, r.CooldownHours,
+			r.ChildLimit, r.ChildLimitPeriod, days, r.AvailableFrom, r.AvailableUntil,
+			r.MinAge, r.MaxAge, r.RequiresChores, r.ID, r.UserID)
 		if err != nil {
 			return err
 		}
 	}
-	return nil
+	return r.saveTargeting(db)
 }
 
 // function to get a reward by ID from the database
-func GetRewardByID(db *sql.DB, id int64) (*Reward, error) {
-	query := "SELECT id, user_id, description, point_cost FROM rewards WHERE id = ?"
-	row := db.QueryRow(query, id)
-
-	reward := &Reward{}
-	err := row.Scan(&reward.ID, &reward.UserID, &reward.Description, &reward.PointCost)
+func GetRewardByID(db DBTX, id int64) (*Reward, error) {
+	query := "SELECT " + rewardColumns + " FROM rewards WHERE id = ?"
+	reward, err := scanReward(db.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("no reward found with ID %d", id)
@@ -44,12 +71,17 @@ func GetRewardByID(db *sql.DB, id int64) (*Reward, error) {
 		return nil, err
 	}
 
+	err = loadRewardTargeting(db, []*Reward{reward})
+	if err != nil {
+		return nil, err
+	}
+
 	return reward, nil
 }
 
 // function to get all rewards from the database
 func GetAllRewards(db *sql.DB) ([]*Reward, error) {
-	query := "SELECT id, description, point_cost FROM rewards"
+	query := "SELECT " + rewardColumns + " FROM rewards"
 	rows, err := db.Query(query)
 	if err != nil {
 		return nil, err
@@ -58,8 +90,7 @@ func GetAllRewards(db *sql.DB) ([]*Reward, error) {
 
 	var rewards []*Reward
 	for rows.Next() {
-		reward := &Reward{}
-		err := rows.Scan(&reward.ID, &reward.Description, &reward.PointCost)
+		reward, err := scanReward(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -68,12 +99,17 @@ func GetAllRewards(db *sql.DB) ([]*Reward, error) {
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+
+	err = loadRewardTargeting(db, rewards)
+	if err != nil {
+		return nil, err
+	}
 	return rewards, nil
 }
 
 // function to get rewards by user ID from the database
 func GetRewardsByUserID(db *sql.DB, userID int) ([]*Reward, error) {
-	query := "SELECT id, description, point_cost FROM rewards WHERE user_id = ?"
+	query := "SELECT " + rewardColumns + " FROM rewards WHERE user_id = ?"
 	rows, err := db.Query(query, userID)
 	if err != nil {
 		return nil, err
@@ -82,8 +118,7 @@ func GetRewardsByUserID(db *sql.DB, userID int) ([]*Reward, error) {
 
 	var rewards []*Reward
 	for rows.Next() {
-		reward := &Reward{}
-		err := rows.Scan(&reward.ID, &reward.Description, &reward.PointCost)
+		reward, err := scanReward(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -92,11 +127,23 @@ func GetRewardsByUserID(db *sql.DB, userID int) ([]*Reward, error) {
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+
+	err = loadRewardTargeting(db, rewards)
+	if err != nil {
+		return nil, err
+	}
 	return rewards, nil
 }
 
 // function to delete a reward from the database
 func DeleteReward(db *sql.DB, id int64) error {
+	for _, table := range []string{"savings_goals", "reward_targets", "reward_prices"} {
+		_, err := db.Exec("DELETE FROM "+table+" WHERE reward_id = ?", id)
+		if err != nil {
+			return err
+		}
+	}
+
 	result, err := db.Exec("DELETE FROM rewards WHERE id = ?", id)
 	if err != nil {
 		return err
@@ -113,3 +160,24 @@ func DeleteReward(db *sql.DB, id int64) error {
 
 	return nil
 }
+
+// function to parse a comma separated list of weekday numbers
+func parseDays(s string) []int {
+	var days []int
+	for _, item := range splitList(s) {
+		day, err := strconv.Atoi(item)
+		if err == nil && day >= 0 && day <= 6 {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// function to join weekday numbers for storage
+func joinDays(days []int) string {
+	items := make([]string, len(days))
+	for i, day := range days {
+		items[i] = strconv.Itoa(day)
+	}
+	return strings.Join(items, ",")
+}
@@ -0,0 +1,348 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// periods a reward's stock and per-child limits can reset on
+var LimitPeriods = []string{"day", "week", "month"}
+
+var Weekdays = []time.Weekday{
+	time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday,
+}
+
+const timestampLayout = "2006-01-02 15:04:05"
+
+// UnavailableError explains why a reward can't be redeemed right now
+type UnavailableError struct {
+	Reason string
+}
+
+func (e *UnavailableError) Error() string {
+	return e.Reason
+}
+
+// function to get the start of the day, week (from Monday) or month containing
+// now. Any other period returns the zero time so every redemption counts.
+func PeriodStart(period string, now time.Time) time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch period {
+	case "day":
+		return day
+	case "week":
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case "month":
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	}
+	return time.Time{}
+}
+
+// function to describe a limit period for display
+func periodLabel(period string) string {
+	switch period {
+	case "day":
+		return "today"
+	case "week":
+		return "this week"
+	case "month":
+		return "this month"
+	}
+	return ""
+}
+
+// function to check whether the reward is offered on a weekday
+func (r *Reward) AvailableOn(day time.Weekday) bool {
+	if len(r.AvailableDays) == 0 {
+		return true
+	}
+	for _, d := range r.AvailableDays {
+		if d == int(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// function to check whether the reward has any limits configured
+func (r *Reward) HasLimits() bool {
+	return r.Stock > 0 || r.CooldownHours > 0 || r.ChildLimit > 0 ||
+		len(r.AvailableDays) > 0 || r.AvailableFrom != "" || r.AvailableUntil != "" || r.RequiresChores
+}
+
+// function to summarise the reward's limits for the parent panel
+func (r *Reward) LimitSummary() string {
+	var parts []string
+	if r.Stock > 0 {
+		if r.StockPeriod != "" {
+			parts = append(parts, fmt.Sprintf("%d per %s", r.Stock, r.StockPeriod))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d in stock", r.Stock))
+		}
+	}
+	if r.ChildLimit > 0 {
+		period := r.ChildLimitPeriod
+		if period == "" {
+			period = "child"
+		} else {
+			period = "child per " + period
+		}
+		parts = append(parts, fmt.Sprintf("%d per %s", r.ChildLimit, period))
+	}
+	if r.CooldownHours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh cooldown", r.CooldownHours))
+	}
+	if len(r.AvailableDays) > 0 {
+		var days []string
+		for _, d := range Weekdays {
+			if r.AvailableOn(d) {
+				days = append(days, d.String()[:3])
+			}
+		}
+		parts = append(parts, strings.Join(days, "/"))
+	}
+	if r.AvailableFrom != "" || r.AvailableUntil != "" {
+		parts = append(parts, r.windowLabel())
+	}
+	if r.RequiresChores {
+		parts = append(parts, "needs required chores")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (r *Reward) windowLabel() string {
+	switch {
+	case r.AvailableFrom == "":
+		return "until " + r.AvailableUntil
+	case r.AvailableUntil == "":
+		return "from " + r.AvailableFrom
+	}
+	return r.AvailableFrom + "-" + r.AvailableUntil
+}
+
+// function to check whether the time of day is inside the reward's window.
+// A window whose end is before its start runs past midnight.
+func (r *Reward) inWindow(now time.Time) bool {
+	clock := now.Format("15:04")
+	from, until := r.AvailableFrom, r.AvailableUntil
+	switch {
+	case from == "" && until == "":
+		return true
+	case from == "":
+		return clock < until
+	case until == "":
+		return clock >= from
+	case from <= until:
+		return clock >= from && clock < until
+	}
+	return clock >= from || clock < until
+}
+
+// function to record a redemption
+func (rr *RewardRedemption) Save(db DBTX) error {
+	if rr.RedeemedAt.IsZero() {
+		rr.RedeemedAt = time.Now()
+	}
+
+	result, err := db.Exec("INSERT INTO reward_redemptions (user_id, child_id, reward_id, description, points, redeemed_at) VALUES (?, ?, ?, ?, ?, ?)",
+		rr.UserID, rr.ChildID, rr.RewardID, rr.Description, rr.Points, rr.RedeemedAt.UTC().Format(timestampLayout))
+	if err != nil {
+		return fmt.Errorf("failed to save redemption: %v", err)
+	}
+
+	rr.ID, err = result.LastInsertId()
+	return err
+}
+
+// function to count redemptions of a reward since a time; childID 0 counts
+// the whole household
+func CountRedemptions(db DBTX, rewardID, childID int64, since time.Time) (int, error) {
+	query := "SELECT COUNT(*) FROM reward_redemptions WHERE reward_id = ? AND redeemed_at >= ?"
+	args := []interface{}{rewardID, since.UTC().Format(timestampLayout)}
+	if childID != 0 {
+		query += " AND child_id = ?"
+		args = append(args, childID)
+	}
+
+	var count int
+	err := db.QueryRow(query, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count redemptions: %v", err)
+	}
+	return count, nil
+}
+
+// function to get a child's most recent redemptions, newest first
+func GetRedemptionsByChild(db DBTX, childID int64, limit int) ([]*RewardRedemption, error) {
+	rows, err := db.Query(`
+		SELECT id, user_id, child_id, reward_id, description, points, redeemed_at
+		FROM reward_redemptions
+		WHERE child_id = ?
+		ORDER BY redeemed_at DESC, id DESC
+		LIMIT ?
+	`, childID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get redemptions: %v", err)
+	}
+	defer rows.Close()
+
+	var redemptions []*RewardRedemption
+	for rows.Next() {
+		rr := &RewardRedemption{}
+		err := rows.Scan(&rr.ID, &rr.UserID, &rr.ChildID, &rr.RewardID, &rr.Description, &rr.Points, &rr.RedeemedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan redemption: %v", err)
+		}
+		redemptions = append(redemptions, rr)
+	}
+
+	return redemptions, rows.Err()
+}
+
+// function to get when a child last redeemed a reward
+func LastRedemption(db DBTX, rewardID, childID int64) (time.Time, bool, error) {
+	var last sql.NullString
+	err := db.QueryRow("SELECT MAX(redeemed_at) FROM reward_redemptions WHERE reward_id = ? AND child_id = ?", rewardID, childID).Scan(&last)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get last redemption: %v", err)
+	}
+	if !last.Valid {
+		return time.Time{}, false, nil
+	}
+
+	value := last.String
+	if len(value) > len(timestampLayout) {
+		value = value[:len(timestampLayout)]
+	}
+	t, err := time.ParseInLocation(timestampLayout, value, time.UTC)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to parse redemption time: %v", err)
+	}
+	return t, true, nil
+}
+
+// function to check whether a child can redeem the reward at the given
+// time. Returns an empty reason when the reward is available.
+func (r *Reward) Unavailable(db DBTX, childID int64, now time.Time) (string, error) {
+	if !r.AvailableOn(now.Weekday()) {
+		return "Not available on " + now.Weekday().String() + "s", nil
+	}
+
+	if !r.inWindow(now) {
+		return "Only available " + r.windowLabel(), nil
+	}
+
+	if r.Stock > 0 {
+		count, err := CountRedemptions(db, r.ID, 0, PeriodStart(r.StockPeriod, now))
+		if err != nil {
+			return "", err
+		}
+		if count >= r.Stock {
+			if r.StockPeriod != "" {
+				return "Sold out " + periodLabel(r.StockPeriod), nil
+			}
+			return "Sold out", nil
+		}
+	}
+
+	if r.ChildLimit > 0 {
+		count, err := CountRedemptions(db, r.ID, childID, PeriodStart(r.ChildLimitPeriod, now))
+		if err != nil {
+			return "", err
+		}
+		if count >= r.ChildLimit {
+			if r.ChildLimitPeriod != "" {
+				return fmt.Sprintf("Limit of %d reached %s", r.ChildLimit, periodLabel(r.ChildLimitPeriod)), nil
+			}
+			return fmt.Sprintf("Limit of %d reached", r.ChildLimit), nil
+		}
+	}
+
+	if r.CooldownHours > 0 {
+		last, ok, err := LastRedemption(db, r.ID, childID)
+		if err != nil {
+			return "", err
+		}
+		ready := last.Add(time.Duration(r.CooldownHours) * time.Hour)
+		if ok && now.Before(ready) {
+			return "Available again " + ready.In(now.Location()).Format("Mon 3:04 PM"), nil
+		}
+	}
+
+	return "", nil
+}
+
+// function to redeem a reward for a child: checks the reward's limits and the
+// child's balance again, deducts the price and records the redemption in a
+// single transaction
+func RedeemReward(db *sql.DB, child *Child, reward *Reward, price int, now time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	current, err := GetChildByID(tx, child.ID)
+	if err != nil {
+		return err
+	}
+	*child = *current
+
+	// Points locked for a savings goal can only be spent on that goal, and in
+	// jar mode only the spend jar can be used in the store
+	spendable, err := GetRewardSpendablePoints(tx, child, reward.ID)
+	if err != nil {
+		return err
+	}
+	if spendable < price {
+		return ErrNotEnoughPoints
+	}
+
+	reason, err := reward.Unavailable(tx, child.ID, now)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		return &UnavailableError{Reason: reason}
+	}
+
+	child.Points -= price
+	if child.Rewards == "" {
+		child.Rewards = reward.Description
+	} else {
+		child.Rewards += ", " + reward.Description
+	}
+
+	err = child.Save(tx)
+	if err != nil {
+		return err
+	}
+
+	redemption := &RewardRedemption{
+		UserID:      child.UserID,
+		ChildID:     child.ID,
+		RewardID:    reward.ID,
+		Description: reward.Description,
+		Points:      price,
+		RedeemedAt:  now,
+	}
+	err = redemption.Save(tx)
+	if err != nil {
+		return err
+	}
+
+	err = RecordPoints(tx, child, -price, PointsSpent, reward.Description)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit redemption: %v", err)
+	}
+
+	return nil
+}
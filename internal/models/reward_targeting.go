@@ -0,0 +1,167 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// function to replace a reward's target children and price overrides
+func (r *Reward) saveTargeting(db DBTX) error {
+	for _, table := range []string{"reward_targets", "reward_prices"} {
+		_, err := db.Exec("DELETE FROM "+table+" WHERE reward_id = ?", r.ID)
+		if err != nil {
+			return fmt.Errorf("failed to clear %s: %v", table, err)
+		}
+	}
+
+	for _, childID := range r.ChildIDs {
+		_, err := db.Exec("INSERT INTO reward_targets (reward_id, child_id) VALUES (?, ?)", r.ID, childID)
+		if err != nil {
+			return fmt.Errorf("failed to save reward target: %v", err)
+		}
+	}
+
+	for childID, price := range r.Prices {
+		_, err := db.Exec("INSERT INTO reward_prices (reward_id, child_id, point_cost) VALUES (?, ?, ?)", r.ID, childID, price)
+		if err != nil {
+			return fmt.Errorf("failed to save reward price: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// function to load target children and price overrides for a list of rewards
+func loadRewardTargeting(db DBTX, rewards []*Reward) error {
+	if len(rewards) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*Reward, len(rewards))
+	placeholders := make([]string, len(rewards))
+	args := make([]interface{}, len(rewards))
+	for i, reward := range rewards {
+		byID[reward.ID] = reward
+		reward.ChildIDs = nil
+		reward.Prices = make(map[int64]int)
+		placeholders[i] = "?"
+		args[i] = reward.ID
+	}
+	in := "(" + strings.Join(placeholders, ", ") + ")"
+
+	rows, err := db.Query("SELECT reward_id, child_id FROM reward_targets WHERE reward_id IN "+in, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get reward targets: %v", err)
+	}
+	for rows.Next() {
+		var rewardID, childID int64
+		err := rows.Scan(&rewardID, &childID)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan reward target: %v", err)
+		}
+		byID[rewardID].ChildIDs = append(byID[rewardID].ChildIDs, childID)
+	}
+	rows.Close()
+
+	rows, err = db.Query("SELECT reward_id, child_id, point_cost FROM reward_prices WHERE reward_id IN "+in, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get reward prices: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rewardID, childID int64
+		var price int
+		err := rows.Scan(&rewardID, &childID, &price)
+		if err != nil {
+			return fmt.Errorf("failed to scan reward price: %v", err)
+		}
+		byID[rewardID].Prices[childID] = price
+	}
+
+	return rows.Err()
+}
+
+// function to check whether the reward is targeted at a specific child
+func (r *Reward) TargetsChild(childID int64) bool {
+	for _, id := range r.ChildIDs {
+		if id == childID {
+			return true
+		}
+	}
+	return false
+}
+
+// function to check whether a child can see the reward. Children without a
+// birthdate are not filtered by age.
+func (r *Reward) VisibleTo(child *Child) bool {
+	if len(r.ChildIDs) > 0 && !r.TargetsChild(child.ID) {
+		return false
+	}
+
+	if child.Birthdate == "" {
+		return true
+	}
+
+	age := child.Age()
+	if r.MinAge > 0 && age < r.MinAge {
+		return false
+	}
+	if r.MaxAge > 0 && age > r.MaxAge {
+		return false
+	}
+	return true
+}
+
+// function to get what a child pays for the reward
+func (r *Reward) PriceFor(child *Child) int {
+	if price, ok := r.Prices[child.ID]; ok {
+		return price
+	}
+	return r.PointCost
+}
+
+// function to get a child's price override for the reward form; 0 when unset
+func (r *Reward) PriceOverride(childID int64) int {
+	return r.Prices[childID]
+}
+
+// function to check whether the reward is limited to some children or ages
+func (r *Reward) IsTargeted() bool {
+	return len(r.ChildIDs) > 0 || r.MinAge > 0 || r.MaxAge > 0
+}
+
+// function to describe who the reward is for in the parent panel
+func (r *Reward) TargetSummary() string {
+	var parts []string
+	switch len(r.ChildIDs) {
+	case 0:
+	case 1:
+		parts = append(parts, "1 child")
+	default:
+		parts = append(parts, fmt.Sprintf("%d children", len(r.ChildIDs)))
+	}
+	switch {
+	case r.MinAge > 0 && r.MaxAge > 0:
+		parts = append(parts, fmt.Sprintf("ages %d-%d", r.MinAge, r.MaxAge))
+	case r.MinAge > 0:
+		parts = append(parts, fmt.Sprintf("ages %d+", r.MinAge))
+	case r.MaxAge > 0:
+		parts = append(parts, fmt.Sprintf("up to age %d", r.MaxAge))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// function to get the rewards a child can see, priced for that child
+func RewardsForChild(rewards []*Reward, child *Child) []*Reward {
+	var visible []*Reward
+	for _, reward := range rewards {
+		if !reward.VisibleTo(child) {
+			continue
+		}
+		priced := *reward
+		priced.PointCost = reward.PriceFor(child)
+		visible = append(visible, &priced)
+	}
+	return visible
+}
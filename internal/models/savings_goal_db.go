@@ -0,0 +1,114 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// function to save a child's savings goal, replacing any goal they had before
+func (g *SavingsGoal) Save(db *sql.DB) error {
+	query := `
+		INSERT INTO savings_goals (child_id, reward_id, locked_points, notified)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(child_id) DO UPDATE SET
+			reward_id = excluded.reward_id,
+			locked_points = excluded.locked_points,
+			notified = excluded.notified
+	`
+	_, err := db.Exec(query, g.ChildID, g.RewardID, g.LockedPoints, g.Notified)
+	if err != nil {
+		return fmt.Errorf("failed to save savings goal: %v", err)
+	}
+	return nil
+}
+
+// function to get a child's savings goal with its reward, priced for the
+// child; returns nil when the child isn't saving for anything
+func GetSavingsGoal(db DBTX, childID int64) (*SavingsGoal, error) {
+	query := `
+		SELECT g.child_id, g.reward_id, g.locked_points, g.notified, g.created_at,
+			r.id, r.user_id, r.description, COALESCE(p.point_cost, r.point_cost)
+		FROM savings_goals g
+		JOIN rewards r ON r.id = g.reward_id
+		LEFT JOIN reward_prices p ON p.reward_id = g.reward_id AND p.child_id = g.child_id
+		WHERE g.child_id = ?
+	`
+	row := db.QueryRow(query, childID)
+
+	g := &SavingsGoal{Reward: &Reward{}}
+	err := row.Scan(&g.ChildID, &g.RewardID, &g.LockedPoints, &g.Notified, &g.CreatedAt,
+		&g.Reward.ID, &g.Reward.UserID, &g.Reward.Description, &g.Reward.PointCost)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get savings goal: %v", err)
+	}
+
+	return g, nil
+}
+
+// function to remove a child's savings goal
+func DeleteSavingsGoal(db *sql.DB, childID int64) error {
+	_, err := db.Exec("DELETE FROM savings_goals WHERE child_id = ?", childID)
+	if err != nil {
+		return fmt.Errorf("failed to delete savings goal: %v", err)
+	}
+	return nil
+}
+
+// function to get how many points are locked for the goal; never more than
+// the child actually has
+func (g *SavingsGoal) Locked(points int) int {
+	if g == nil || g.LockedPoints <= 0 {
+		return 0
+	}
+	return min(g.LockedPoints, points)
+}
+
+// function to get the percentage of the goal the child has saved
+func (g *SavingsGoal) Progress(points int) int {
+	if g.Reward.PointCost <= 0 || points >= g.Reward.PointCost {
+		return 100
+	}
+	if points <= 0 {
+		return 0
+	}
+	return points * 100 / g.Reward.PointCost
+}
+
+// function to get how many more points the child needs for the goal
+func (g *SavingsGoal) Remaining(points int) int {
+	return max(g.Reward.PointCost-points, 0)
+}
+
+// function to check whether the child can afford the goal
+func (g *SavingsGoal) Affordable(points int) bool {
+	return points >= g.Reward.PointCost
+}
+
+// function to get the points a child can spend on a reward. Locked points
+// only count towards the reward they are being saved for.
+func SpendablePoints(child *Child, goal *SavingsGoal, rewardID int64) int {
+	if goal != nil && goal.RewardID == rewardID {
+		return child.Points
+	}
+	return child.Points - goal.Locked(child.Points)
+}
+
+// function to get the points a child can spend outside the rewards store,
+// leaving savings goals and the save and give jars alone
+func GetSpendablePoints(db DBTX, child *Child) (int, error) {
+	return GetRewardSpendablePoints(db, child, 0)
+}
+
+// function to get the points a child can spend on a reward; points locked
+// for a savings goal count when the reward is the goal
+func GetRewardSpendablePoints(db DBTX, child *Child, rewardID int64) (int, error) {
+	goal, err := GetSavingsGoal(db, child.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	return LimitToSpendJar(db, child, SpendablePoints(child, goal, rewardID))
+}
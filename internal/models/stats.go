@@ -0,0 +1,287 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+// kinds of point transactions that count as earning and spending points;
+// refunds of declined cash outs are netted against spending, and points
+// given away from the give jar count as spent
+var (
+	earnedKinds = []string{PointsEarned, PointsQuest, PointsBonus, PointsInterest}
+	spentKinds  = []string{PointsSpent, PointsCashOut, PointsRefund, PointsGiven}
+)
+
+// points a child earned and spent in one week
+type PointsWeek struct {
+	Start  time.Time
+	Earned int
+	Spent  int
+}
+
+// a child's statistics for a period
+type ChildStats struct {
+	ChildID   int64
+	ChildName string
+	Weeks     []*PointsWeek
+	// Due is how many assignments fell due in the period and Done how many
+	// of those were finished
+	Due  int
+	Done int
+	// RequiredDue is how many required assignments fell due in the period and
+	// OnTime how many of those were finished by their due date
+	RequiredDue int
+	OnTime      int
+}
+
+// how often a chore was approved in a period
+type ChorePopularity struct {
+	ChoreID     int64
+	Description string
+	Icon        string
+	Count       int
+}
+
+// a household's statistics for the weeks up to now
+type Stats struct {
+	From     time.Time
+	Children []*ChildStats
+	// Chores is sorted most popular first
+	Chores []*ChorePopularity
+	// Approvals is how many finished chores were approved in the period and
+	// ApprovalTime the average wait between finishing and approval
+	Approvals    int
+	ApprovalTime time.Duration
+}
+
+// function to get the percentage of due assignments that were finished;
+// -1 when nothing was due
+func (s *ChildStats) CompletionRate() int {
+	return percent(s.Done, s.Due)
+}
+
+// function to get the percentage of required assignments finished on time;
+// -1 when nothing required was due
+func (s *ChildStats) OnTimeRate() int {
+	return percent(s.OnTime, s.RequiredDue)
+}
+
+func percent(part, whole int) int {
+	if whole == 0 {
+		return -1
+	}
+	return part * 100 / whole
+}
+
+// function to get up to n of the most approved chores
+func (s *Stats) MostPopular(n int) []*ChorePopularity {
+	return s.Chores[:min(n, len(s.Chores))]
+}
+
+// function to get up to n of the least approved chores, least first
+func (s *Stats) LeastPopular(n int) []*ChorePopularity {
+	var least []*ChorePopularity
+	for i := len(s.Chores) - 1; i >= 0 && len(least) < n; i-- {
+		least = append(least, s.Chores[i])
+	}
+	return least
+}
+
+// function to describe the average approval wait for display
+func (s *Stats) ApprovalTimeLabel() string {
+	if s.Approvals == 0 {
+		return "no approvals yet"
+	}
+	wait := s.ApprovalTime.Round(time.Minute)
+	switch {
+	case wait < time.Hour:
+		return fmt.Sprintf("%d min", int(wait.Minutes()))
+	case wait < 24*time.Hour:
+		return fmt.Sprintf("%dh %dm", int(wait.Hours()), int(wait.Minutes())%60)
+	}
+	return fmt.Sprintf("%.1f days", wait.Hours()/24)
+}
+
+// function to find the week a time falls in, comparing calendar days so a
+// week that crosses a daylight saving change is still seven days long
+func weekOf(weeks []*PointsWeek, t time.Time) *PointsWeek {
+	day := startOfDay(t)
+	for _, w := range weeks {
+		if !day.Before(w.Start) && day.Before(w.Start.AddDate(0, 0, 7)) {
+			return w
+		}
+	}
+	return nil
+}
+
+// function to gather a household's statistics for the given number of weeks
+// up to now, starting on a Monday
+func GetStats(db DBTX, userID int, weeks int, now time.Time) (*Stats, error) {
+	now = now.In(time.Local)
+	stats := &Stats{From: PeriodStart("week", now).AddDate(0, 0, -7*(weeks-1))}
+	from := stats.From.UTC().Format(timestampLayout)
+
+	rows, err := db.Query("SELECT id, name FROM children WHERE user_id = ? ORDER BY name", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get children: %v", err)
+	}
+
+	byChild := make(map[int64]*ChildStats)
+	for rows.Next() {
+		c := &ChildStats{}
+		if err := rows.Scan(&c.ChildID, &c.ChildName); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan child: %v", err)
+		}
+		for i := 0; i < weeks; i++ {
+			c.Weeks = append(c.Weeks, &PointsWeek{Start: stats.From.AddDate(0, 0, 7*i)})
+		}
+		stats.Children = append(stats.Children, c)
+		byChild[c.ChildID] = c
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error iterating over children: %v", err)
+	}
+
+	// Points earned and spent, by week
+	rows, err = db.Query(`
+		SELECT child_id, amount, kind, created_at
+		FROM point_transactions
+		WHERE user_id = ? AND created_at >= ?
+	`, userID, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get point history: %v", err)
+	}
+	for rows.Next() {
+		var childID int64
+		var amount int
+		var kind string
+		var createdAt time.Time
+		if err := rows.Scan(&childID, &amount, &kind, &createdAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan point transaction: %v", err)
+		}
+
+		c, ok := byChild[childID]
+		if !ok {
+			continue
+		}
+		week := weekOf(c.Weeks, createdAt)
+		if week == nil {
+			continue
+		}
+		if slices.Contains(earnedKinds, kind) {
+			week.Earned += amount
+		} else if slices.Contains(spentKinds, kind) {
+			week.Spent -= amount
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error iterating over point history: %v", err)
+	}
+
+	// Completion and on-time rates of the assignments that fell due before today
+	rows, err = db.Query(`
+		SELECT a.child_id, a.due_date, a.completed_at, COALESCE(c.is_required, 0)
+		FROM assignments a
+		LEFT JOIN chores c ON a.chore_id = c.id
+		WHERE a.user_id = ? AND a.due_date >= ? AND a.due_date < ?
+	`, userID, stats.From.Format(DueDateLayout), now.Format(DueDateLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due assignments: %v", err)
+	}
+	for rows.Next() {
+		a := &Assignment{}
+		var isRequired bool
+		if err := rows.Scan(&a.ChildID, &a.DueDate, &a.CompletedAt, &isRequired); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan due assignment: %v", err)
+		}
+
+		c, ok := byChild[a.ChildID]
+		if !ok {
+			continue
+		}
+		c.Due++
+		if a.CompletedAt.Valid {
+			c.Done++
+		}
+		if isRequired {
+			c.RequiredDue++
+			if a.CompletedAt.Valid && a.CompletedAt.Time.In(time.Local).Format(DueDateLayout) <= a.DueDate {
+				c.OnTime++
+			}
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error iterating over due assignments: %v", err)
+	}
+
+	// Time from finishing a chore to its approval
+	rows, err = db.Query(`
+		SELECT completed_at, approved_at
+		FROM assignments
+		WHERE user_id = ? AND approved_at >= ? AND completed_at IS NOT NULL
+	`, userID, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approvals: %v", err)
+	}
+	var waited time.Duration
+	for rows.Next() {
+		var completedAt, approvedAt time.Time
+		if err := rows.Scan(&completedAt, &approvedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan approval: %v", err)
+		}
+		waited += max(approvedAt.Sub(completedAt), 0)
+		stats.Approvals++
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error iterating over approvals: %v", err)
+	}
+	if stats.Approvals > 0 {
+		stats.ApprovalTime = waited / time.Duration(stats.Approvals)
+	}
+
+	// How often each chore was approved, counting chores nobody did
+	rows, err = db.Query(`
+		SELECT c.id, c.description, c.icon,
+			(SELECT COUNT(*) FROM assignments a WHERE a.chore_id = c.id AND a.approved_at >= ?)
+		FROM chores c
+		WHERE c.user_id = ? AND c.is_bounty = 0
+	`, from, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chore popularity: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		p := &ChorePopularity{}
+		if err := rows.Scan(&p.ChoreID, &p.Description, &p.Icon, &p.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan chore popularity: %v", err)
+		}
+		stats.Chores = append(stats.Chores, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over chores: %v", err)
+	}
+
+	sort.SliceStable(stats.Chores, func(i, j int) bool {
+		if stats.Chores[i].Count != stats.Chores[j].Count {
+			return stats.Chores[i].Count > stats.Chores[j].Count
+		}
+		return stats.Chores[i].Description < stats.Chores[j].Description
+	})
+
+	return stats, nil
+}
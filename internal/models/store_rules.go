@@ -0,0 +1,93 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// how the rewards store is locked while a child has required chores waiting
+// to be approved
+const (
+	StoreLockOff      = "off"
+	StoreLockAll      = "store"
+	StoreLockSelected = "rewards"
+)
+
+var StoreLockModes = []string{StoreLockOff, StoreLockAll, StoreLockSelected}
+
+// function to get a household's store rules, falling back to no lock
+func GetStoreRules(db DBTX, userID int) (*StoreRules, error) {
+	s := &StoreRules{UserID: userID, RequiredChoresLock: StoreLockOff}
+
+	err := db.QueryRow("SELECT required_chores_lock FROM store_rules WHERE user_id = ?", userID).Scan(&s.RequiredChoresLock)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get store rules: %v", err)
+	}
+
+	return s, nil
+}
+
+// function to save a household's store rules
+func (s *StoreRules) Save(db *sql.DB) error {
+	if !slices.Contains(StoreLockModes, s.RequiredChoresLock) {
+		return fmt.Errorf("invalid store lock: %s", s.RequiredChoresLock)
+	}
+
+	_, err := db.Exec(`INSERT INTO store_rules (user_id, required_chores_lock) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET required_chores_lock = excluded.required_chores_lock`,
+		s.UserID, s.RequiredChoresLock)
+	if err != nil {
+		return fmt.Errorf("failed to save store rules: %v", err)
+	}
+	return nil
+}
+
+// function to check whether a reward is locked by open required chores
+func (s *StoreRules) Locks(reward *Reward) bool {
+	switch s.RequiredChoresLock {
+	case StoreLockAll:
+		return true
+	case StoreLockSelected:
+		return reward.RequiresChores
+	}
+	return false
+}
+
+// function to get a child's required chores that haven't been approved yet
+// and are owed for the current period. Dated assignments count when they are
+// overdue or due before the end of the current day (daily), or week (weekly
+// and one-off), so the next occurrence of a repeating chore doesn't keep the
+// store locked. Undated assignments count only when they don't repeat.
+func GetBlockingChores(db *sql.DB, childID int64, now time.Time) ([]*Assignment, error) {
+	assignments, err := GetAssignmentsByChild(db, childID)
+	if err != nil {
+		return nil, err
+	}
+
+	now = now.In(time.Local)
+	var blocking []*Assignment
+	for _, assignment := range assignments {
+		if !assignment.Chore.IsRequired {
+			continue
+		}
+
+		due, ok := assignment.Due()
+		if !ok {
+			if assignment.Recurrence == RecurrenceNone {
+				blocking = append(blocking, assignment)
+			}
+			continue
+		}
+
+		end := PeriodStart("week", now).AddDate(0, 0, 7)
+		if assignment.Recurrence == RecurrenceDaily {
+			end = PeriodStart("day", now).AddDate(0, 0, 1)
+		}
+		if due.Before(end) {
+			blocking = append(blocking, assignment)
+		}
+	}
+	return blocking, nil
+}
@@ -0,0 +1,352 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// states of a chore swap
+const (
+	SwapPending   = "pending"
+	SwapAccepted  = "accepted"
+	SwapCompleted = "completed"
+	SwapDeclined  = "declined"
+	SwapCancelled = "cancelled"
+	SwapRejected  = "rejected"
+)
+
+// labels of the swap states for display
+var SwapStatusLabels = map[string]string{
+	SwapPending:   "Waiting for an answer",
+	SwapAccepted:  "Waiting for a parent",
+	SwapCompleted: "Swapped",
+	SwapDeclined:  "Declined",
+	SwapCancelled: "Cancelled",
+	SwapRejected:  "Not approved",
+}
+
+// function to get a household's swap rules; swaps don't need approval by default
+func GetSwapRules(db DBTX, userID int) (*SwapRules, error) {
+	s := &SwapRules{UserID: userID}
+
+	err := db.QueryRow("SELECT require_approval FROM swap_rules WHERE user_id = ?", userID).Scan(&s.RequireApproval)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get swap rules: %v", err)
+	}
+
+	return s, nil
+}
+
+// function to save a household's swap rules
+func (s *SwapRules) Save(db *sql.DB) error {
+	_, err := db.Exec(`INSERT INTO swap_rules (user_id, require_approval) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET require_approval = excluded.require_approval`,
+		s.UserID, s.RequireApproval)
+	if err != nil {
+		return fmt.Errorf("failed to save swap rules: %v", err)
+	}
+	return nil
+}
+
+const swapColumns = `s.id, s.user_id, s.from_child_id, f.name, s.to_child_id, t.name, s.offered_assignment_id, s.offered_chore,
+	s.requested_assignment_id, s.requested_chore, s.points, s.note, s.status, s.created_at, s.resolved_at`
+
+const swapTables = `chore_swaps s
+	JOIN children f ON f.id = s.from_child_id
+	JOIN children t ON t.id = s.to_child_id`
+
+func scanSwap(row interface{ Scan(...interface{}) error }) (*ChoreSwap, error) {
+	s := &ChoreSwap{}
+	err := row.Scan(&s.ID, &s.UserID, &s.FromChildID, &s.FromChildName, &s.ToChildID, &s.ToChildName, &s.OfferedAssignmentID, &s.OfferedChore,
+		&s.RequestedAssignmentID, &s.RequestedChore, &s.Points, &s.Note, &s.Status, &s.CreatedAt, &s.ResolvedAt)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// function to get the most recent swaps matching a where clause, newest first
+func getSwaps(db DBTX, limit int, where string, args ...interface{}) ([]*ChoreSwap, error) {
+	rows, err := db.Query("SELECT "+swapColumns+" FROM "+swapTables+" WHERE "+where+" ORDER BY s.created_at DESC, s.id DESC LIMIT ?", append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chore swaps: %v", err)
+	}
+	defer rows.Close()
+
+	var swaps []*ChoreSwap
+	for rows.Next() {
+		s, err := scanSwap(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan chore swap: %v", err)
+		}
+		swaps = append(swaps, s)
+	}
+	return swaps, rows.Err()
+}
+
+// function to get a swap by ID
+func GetSwapByID(db DBTX, id int64) (*ChoreSwap, error) {
+	s, err := scanSwap(db.QueryRow("SELECT "+swapColumns+" FROM "+swapTables+" WHERE s.id = ?", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no chore swap found with ID %d", id)
+		}
+		return nil, fmt.Errorf("failed to get chore swap: %v", err)
+	}
+	return s, nil
+}
+
+// function to get the swaps a child has offered or been offered, newest first
+func GetSwapsByChild(db DBTX, childID int64, limit int) ([]*ChoreSwap, error) {
+	return getSwaps(db, limit, "s.from_child_id = ? OR s.to_child_id = ?", childID, childID)
+}
+
+// function to get a household's swaps, newest first
+func GetSwapsByUserID(db DBTX, userID int, limit int) ([]*ChoreSwap, error) {
+	return getSwaps(db, limit, "s.user_id = ?", userID)
+}
+
+// function to check whether the swap is still waiting on someone
+func (s *ChoreSwap) Open() bool {
+	return s.Status == SwapPending || s.Status == SwapAccepted
+}
+
+// function to get the label of the swap's state
+func (s *ChoreSwap) StatusLabel() string {
+	return SwapStatusLabels[s.Status]
+}
+
+// function to load an open assignment of a child for a swap
+func swapAssignment(db DBTX, assignmentID, childID int64) (*Assignment, error) {
+	a := &Assignment{ID: assignmentID, Chore: &Chore{}}
+	err := db.QueryRow(`
+		SELECT a.child_id, a.is_completed, c.id, c.description
+		FROM assignments a
+		JOIN chores c ON c.id = a.chore_id
+		WHERE a.id = ?
+	`, assignmentID).Scan(&a.ChildID, &a.IsCompleted, &a.Chore.ID, &a.Chore.Description)
+	if err == sql.ErrNoRows || (err == nil && a.ChildID != childID) {
+		return nil, fmt.Errorf("that chore is no longer assigned")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment: %v", err)
+	}
+	if a.IsCompleted {
+		return nil, fmt.Errorf("\"%s\" has already been completed", a.Chore.Description)
+	}
+	return a, nil
+}
+
+// function to offer a swap to a sibling. The proposer gives away one of
+// their assignments and gets one of the sibling's assignments, points or both.
+func ProposeSwap(db *sql.DB, swap *ChoreSwap) error {
+	if swap.FromChildID == swap.ToChildID {
+		return fmt.Errorf("pick a brother or sister to swap with")
+	}
+	if swap.Points < 0 {
+		return fmt.Errorf("invalid number of points")
+	}
+	if swap.RequestedAssignmentID == 0 && swap.Points == 0 {
+		return fmt.Errorf("offer a chore or some points in return")
+	}
+
+	from, err := GetChildByID(db, swap.FromChildID)
+	if err != nil {
+		return err
+	}
+	to, err := GetChildByID(db, swap.ToChildID)
+	if err != nil {
+		return err
+	}
+	if from.UserID != swap.UserID || to.UserID != swap.UserID {
+		return fmt.Errorf("unauthorized")
+	}
+	swap.FromChildName = from.Name
+	swap.ToChildName = to.Name
+
+	offered, err := swapAssignment(db, swap.OfferedAssignmentID, from.ID)
+	if err != nil {
+		return err
+	}
+	swap.OfferedChore = offered.Chore.Description
+
+	swap.RequestedChore = ""
+	if swap.RequestedAssignmentID != 0 {
+		requested, err := swapAssignment(db, swap.RequestedAssignmentID, to.ID)
+		if err != nil {
+			return err
+		}
+		swap.RequestedChore = requested.Chore.Description
+	}
+
+	// An assignment can only be in one open offer at a time
+	other := swap.RequestedAssignmentID
+	if other == 0 {
+		other = offered.ID
+	}
+	var busy bool
+	err = db.QueryRow(`SELECT EXISTS(SELECT 1 FROM chore_swaps WHERE status IN (?, ?)
+		AND (offered_assignment_id IN (?, ?) OR requested_assignment_id IN (?, ?)))`,
+		SwapPending, SwapAccepted, offered.ID, other, offered.ID, other).Scan(&busy)
+	if err != nil {
+		return fmt.Errorf("failed to check open swaps: %v", err)
+	}
+	if busy {
+		return fmt.Errorf("one of these chores is already part of a swap")
+	}
+
+	swap.Status = SwapPending
+	result, err := db.Exec(`INSERT INTO chore_swaps (user_id, from_child_id, to_child_id, offered_assignment_id, offered_chore,
+		requested_assignment_id, requested_chore, points, note, status) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		swap.UserID, swap.FromChildID, swap.ToChildID, swap.OfferedAssignmentID, swap.OfferedChore,
+		swap.RequestedAssignmentID, swap.RequestedChore, swap.Points, swap.Note, swap.Status)
+	if err != nil {
+		return fmt.Errorf("failed to save chore swap: %v", err)
+	}
+
+	swap.ID, err = result.LastInsertId()
+	return err
+}
+
+// function for the sibling to accept a swap. It goes through straight away
+// unless the household wants a parent to approve swaps first.
+func AcceptSwap(db *sql.DB, swap *ChoreSwap, rules *SwapRules, now time.Time) error {
+	if swap.Status != SwapPending {
+		return fmt.Errorf("this swap is no longer waiting for an answer")
+	}
+
+	if rules.RequireApproval {
+		return setSwapStatus(db, swap, SwapAccepted, false, now)
+	}
+	return executeSwap(db, swap, now)
+}
+
+// function for a parent to approve an accepted swap
+func ApproveSwap(db *sql.DB, swap *ChoreSwap, now time.Time) error {
+	if swap.Status != SwapAccepted {
+		return fmt.Errorf("this swap isn't waiting for a parent")
+	}
+	return executeSwap(db, swap, now)
+}
+
+// function to close an open swap without carrying it out
+func CloseSwap(db *sql.DB, swap *ChoreSwap, status string, now time.Time) error {
+	if status != SwapDeclined && status != SwapCancelled && status != SwapRejected {
+		return fmt.Errorf("invalid swap status: %s", status)
+	}
+	if !swap.Open() {
+		return fmt.Errorf("this swap is already closed")
+	}
+	return setSwapStatus(db, swap, status, true, now)
+}
+
+func setSwapStatus(db DBTX, swap *ChoreSwap, status string, resolved bool, now time.Time) error {
+	var resolvedAt interface{}
+	if resolved {
+		resolvedAt = now.UTC().Format(timestampLayout)
+	}
+
+	_, err := db.Exec("UPDATE chore_swaps SET status = ?, resolved_at = ? WHERE id = ?", status, resolvedAt, swap.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update chore swap: %v", err)
+	}
+	swap.Status = status
+	return nil
+}
+
+// function to carry out a swap: the assignments change hands and any points
+// move from the proposer to the sibling, all in one transaction
+func executeSwap(db *sql.DB, swap *ChoreSwap, now time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	offered, err := swapAssignment(tx, swap.OfferedAssignmentID, swap.FromChildID)
+	if err != nil {
+		return err
+	}
+	type move struct {
+		assignment *Assignment
+		to         int64
+	}
+	moves := []move{{offered, swap.ToChildID}}
+
+	if swap.RequestedAssignmentID != 0 {
+		requested, err := swapAssignment(tx, swap.RequestedAssignmentID, swap.ToChildID)
+		if err != nil {
+			return err
+		}
+		moves = append(moves, move{requested, swap.FromChildID})
+	}
+
+	for _, m := range moves {
+		// A child can't end up with the same chore twice
+		var exists bool
+		err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM assignments WHERE child_id = ? AND chore_id = ? AND approved_at IS NULL AND id NOT IN (?, ?))",
+			m.to, m.assignment.Chore.ID, swap.OfferedAssignmentID, swap.RequestedAssignmentID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to check assignments: %v", err)
+		}
+		if exists {
+			return fmt.Errorf("\"%s\" is already assigned to them", m.assignment.Chore.Description)
+		}
+
+		_, err = tx.Exec("UPDATE assignments SET child_id = ? WHERE id = ?", m.to, m.assignment.ID)
+		if err != nil {
+			return fmt.Errorf("failed to reassign chore: %v", err)
+		}
+	}
+
+	if swap.Points > 0 {
+		from, err := GetChildByID(tx, swap.FromChildID)
+		if err != nil {
+			return err
+		}
+		spendable, err := GetSpendablePoints(tx, from)
+		if err != nil {
+			return err
+		}
+		if swap.Points > spendable {
+			return fmt.Errorf("%s doesn't have %d points to spend", swap.FromChildName, swap.Points)
+		}
+
+		to, err := GetChildByID(tx, swap.ToChildID)
+		if err != nil {
+			return err
+		}
+
+		from.Points -= swap.Points
+		err = from.Save(tx)
+		if err != nil {
+			return err
+		}
+		err = RecordPoints(tx, from, -swap.Points, PointsSwap, fmt.Sprintf("Swap with %s: %s", to.Name, swap.OfferedChore))
+		if err != nil {
+			return err
+		}
+
+		to.Points += swap.Points
+		err = to.Save(tx)
+		if err != nil {
+			return err
+		}
+		err = RecordPoints(tx, to, swap.Points, PointsSwap, fmt.Sprintf("Swap with %s: %s", from.Name, swap.OfferedChore))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = setSwapStatus(tx, swap, SwapCompleted, true, now)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit chore swap: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// function to save a webhook subscription to the database
+func (h *Webhook) Save(db *sql.DB) error {
+	eventTypes := strings.Join(h.EventTypes, ",")
+	if h.ID == 0 {
+		result, err := db.Exec("INSERT INTO webhooks (user_id, url, secret, event_types) VALUES (?, ?, ?, ?)",
+			h.UserID, h.URL, h.Secret, eventTypes)
+		if err != nil {
+			return err
+		}
+
+		h.ID, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+	} else {
+		_, err := db.Exec("UPDATE webhooks SET url = ?, event_types = ? WHERE id = ? AND user_id = ?",
+			h.URL, eventTypes, h.ID, h.UserID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// function to check whether a webhook is subscribed to an event type
+func (h *Webhook) Wants(eventType string) bool {
+	for _, t := range h.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// function to get a webhook by ID from the database
+func GetWebhookByID(db *sql.DB, id int64) (*Webhook, error) {
+	query := "SELECT id, user_id, url, secret, event_types, created_at FROM webhooks WHERE id = ?"
+
+	h := &Webhook{}
+	var eventTypes string
+	err := db.QueryRow(query, id).Scan(&h.ID, &h.UserID, &h.URL, &h.Secret, &eventTypes, &h.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no webhook found with ID %d", id)
+		}
+		return nil, err
+	}
+	h.EventTypes = splitList(eventTypes)
+
+	return h, nil
+}
+
+// function to get webhooks by user ID from the database
+func GetWebhooksByUserID(db *sql.DB, userID int) ([]*Webhook, error) {
+	rows, err := db.Query("SELECT id, user_id, url, secret, event_types, created_at FROM webhooks WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []*Webhook
+	for rows.Next() {
+		h := &Webhook{}
+		var eventTypes string
+		err := rows.Scan(&h.ID, &h.UserID, &h.URL, &h.Secret, &eventTypes, &h.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		h.EventTypes = splitList(eventTypes)
+		webhooks = append(webhooks, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// function to delete a webhook and its delivery log from the database
+func DeleteWebhook(db *sql.DB, id int64) error {
+	_, err := db.Exec("DELETE FROM webhook_deliveries WHERE webhook_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting webhook deliveries: %v", err)
+	}
+
+	result, err := db.Exec("DELETE FROM webhooks WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting webhook: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no webhook found with ID %d", id)
+	}
+
+	return nil
+}
+
+// function to record a webhook delivery attempt
+func (d *WebhookDelivery) Save(db *sql.DB) error {
+	result, err := db.Exec("INSERT INTO webhook_deliveries (webhook_id, user_id, event_type, payload, attempt, status_code, error, success) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		d.WebhookID, d.UserID, d.EventType, d.Payload, d.Attempt, d.StatusCode, d.Error, d.Success)
+	if err != nil {
+		return err
+	}
+
+	d.ID, err = result.LastInsertId()
+	return err
+}
+
+// function to get the most recent webhook deliveries for a user, newest first
+func GetWebhookDeliveriesByUserID(db *sql.DB, userID int, limit int) ([]*WebhookDelivery, error) {
+	query := `
+		SELECT d.id, d.webhook_id, d.user_id, h.url, d.event_type, d.payload, d.attempt, d.status_code, d.error, d.success, d.created_at
+		FROM webhook_deliveries d
+		JOIN webhooks h ON d.webhook_id = h.id
+		WHERE d.user_id = ?
+		ORDER BY d.id DESC
+		LIMIT ?
+	`
+
+	rows, err := db.Query(query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %v", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*WebhookDelivery
+	for rows.Next() {
+		d := &WebhookDelivery{}
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.UserID, &d.URL, &d.EventType, &d.Payload, &d.Attempt, &d.StatusCode, &d.Error, &d.Success, &d.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %v", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %v", err)
+	}
+
+	return deliveries, nil
+}
+
+// function to split a comma separated list stored in a single column
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// Mailer sends an email. Features that send mail take a Mailer so another
+// way of delivering it can be plugged in.
+type Mailer interface {
+	Send(to, subject, contentType, body string) error
+}
+
+// SMTPMailer sends email through an SMTP server. Addr is a host:port and
+// can point at a local test server such as MailHog during development.
+type SMTPMailer struct {
+	Addr string
+	From string
+	Auth smtp.Auth
+}
+
+// function to create an SMTP mailer; credentials are optional
+func NewSMTPMailer(addr, from, username, password string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		host := addr
+		if i := strings.LastIndex(addr, ":"); i != -1 {
+			host = addr[:i]
+		}
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPMailer{Addr: addr, From: from, Auth: auth}
+}
+
+func (m *SMTPMailer) Send(to, subject, contentType, body string) error {
+	msg := strings.Join([]string{
+		"From: " + m.From,
+		"To: " + sanitizeHeader(to),
+		"Subject: " + sanitizeHeader(subject),
+		"MIME-Version: 1.0",
+		"Content-Type: " + contentType,
+		"",
+		body,
+	}, "\r\n")
+
+	err := smtp.SendMail(m.Addr, m.Auth, m.From, []string{to}, []byte(msg))
+	if err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+
+	return nil
+}
+
+// function to keep user-provided text from injecting extra mail headers
+func sanitizeHeader(v string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(v)
+}
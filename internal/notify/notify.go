@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"Adven-Chores/internal/events"
+	"Adven-Chores/internal/models"
+	"database/sql"
+	"log"
+)
+
+// Notifier delivers a notification to a parent over one channel. Notifiers
+// check the preferences themselves and return nil when their channel is off.
+type Notifier interface {
+	Name() string
+	Notify(prefs *models.NotificationPreferences, n *models.Notification) error
+}
+
+// Dispatcher fans a notification out to every registered notifier according
+// to the parent's preferences
+type Dispatcher struct {
+	db        *sql.DB
+	inbox     Notifier
+	notifiers []Notifier
+}
+
+// function to create a dispatcher; the inbox always runs first and
+// synchronously so the notification is visible as soon as the request returns
+func NewDispatcher(db *sql.DB, notifiers ...Notifier) *Dispatcher {
+	return &Dispatcher{
+		db:        db,
+		inbox:     &InboxNotifier{DB: db},
+		notifiers: notifiers,
+	}
+}
+
+// function to register an additional notifier
+func (d *Dispatcher) Register(n Notifier) {
+	d.notifiers = append(d.notifiers, n)
+}
+
+// function to send a notification to the parent it belongs to
+func (d *Dispatcher) Dispatch(n *models.Notification) {
+	prefs, err := models.GetNotificationPreferences(d.db, n.UserID)
+	if err != nil {
+		log.Printf("Dispatch: failed to load preferences for user %d: %v", n.UserID, err)
+		return
+	}
+
+	if !wants(prefs, n.Kind) {
+		return
+	}
+
+	if err := d.inbox.Notify(prefs, n); err != nil {
+		log.Printf("Dispatch: %s notifier failed: %v", d.inbox.Name(), err)
+	}
+
+	// External channels can be slow, don't hold up the request for them
+	go func() {
+		for _, notifier := range d.notifiers {
+			if err := notifier.Notify(prefs, n); err != nil {
+				log.Printf("Dispatch: %s notifier failed: %v", notifier.Name(), err)
+			}
+		}
+	}()
+}
+
+// function to check whether the parent wants notifications of this kind
+func wants(prefs *models.NotificationPreferences, kind string) bool {
+	switch kind {
+	case events.AssignmentCompleted:
+		return prefs.NotifyCompleted
+	case events.RewardRedeemed:
+		return prefs.NotifyRedeemed
+	}
+	return true
+}
+
+// InboxNotifier stores notifications in the database for the in-app inbox
+type InboxNotifier struct {
+	DB *sql.DB
+}
+
+func (i *InboxNotifier) Name() string {
+	return "inbox"
+}
+
+func (i *InboxNotifier) Notify(prefs *models.NotificationPreferences, n *models.Notification) error {
+	if !prefs.InboxEnabled {
+		return nil
+	}
+	return n.Save(i.DB)
+}
@@ -0,0 +1,27 @@
+package notify
+
+import (
+	"Adven-Chores/internal/models"
+)
+
+// SMTPNotifier emails notifications to the parent
+type SMTPNotifier struct {
+	Mailer Mailer
+}
+
+// function to create an email notifier sending through a mailer
+func NewSMTPNotifier(mailer Mailer) *SMTPNotifier {
+	return &SMTPNotifier{Mailer: mailer}
+}
+
+func (s *SMTPNotifier) Name() string {
+	return "smtp"
+}
+
+func (s *SMTPNotifier) Notify(prefs *models.NotificationPreferences, n *models.Notification) error {
+	if !prefs.EmailEnabled || prefs.Email == "" {
+		return nil
+	}
+
+	return s.Mailer.Send(prefs.Email, n.Title, "text/plain; charset=UTF-8", n.Body)
+}
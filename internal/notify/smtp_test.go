@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"Adven-Chores/internal/models"
+	"Adven-Chores/internal/notify/smtptest"
+	"io"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestSMTPNotifierSendsEmail(t *testing.T) {
+	server := smtptest.NewServer()
+	defer server.Close()
+
+	notifier := NewSMTPNotifier(NewSMTPMailer(server.Addr, "chores@example.com", "", ""))
+	prefs := &models.NotificationPreferences{EmailEnabled: true, Email: "parent@example.com"}
+	n := &models.Notification{
+		Title: "Chore completed\r\nBcc: someone@example.com",
+		Body:  "Sam completed \"Dishes\" and is waiting for 10 points",
+	}
+
+	err := notifier.Notify(prefs, n)
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	if messages[0].From != "chores@example.com" {
+		t.Errorf("from = %q, want chores@example.com", messages[0].From)
+	}
+	if len(messages[0].To) != 1 || messages[0].To[0] != "parent@example.com" {
+		t.Errorf("to = %v, want [parent@example.com]", messages[0].To)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(messages[0].Data))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+
+	// The line break in the title must not start a new header
+	if bcc := msg.Header.Get("Bcc"); bcc != "" {
+		t.Errorf("title injected a Bcc header: %q", bcc)
+	}
+	if subject := msg.Header.Get("Subject"); !strings.HasPrefix(subject, "Chore completed") {
+		t.Errorf("subject = %q", subject)
+	}
+	if contentType := msg.Header.Get("Content-Type"); contentType != "text/plain; charset=UTF-8" {
+		t.Errorf("content type = %q", contentType)
+	}
+
+	body, err := io.ReadAll(msg.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	if strings.TrimSpace(string(body)) != n.Body {
+		t.Errorf("body = %q, want %q", body, n.Body)
+	}
+}
+
+func TestSMTPNotifierSkipsWhenEmailIsOff(t *testing.T) {
+	server := smtptest.NewServer()
+	defer server.Close()
+
+	notifier := NewSMTPNotifier(NewSMTPMailer(server.Addr, "chores@example.com", "", ""))
+
+	for _, prefs := range []*models.NotificationPreferences{
+		{EmailEnabled: false, Email: "parent@example.com"},
+		{EmailEnabled: true, Email: ""},
+	} {
+		err := notifier.Notify(prefs, &models.Notification{Title: "Chore completed"})
+		if err != nil {
+			t.Fatalf("Notify: %v", err)
+		}
+	}
+
+	if messages := server.Messages(); len(messages) != 0 {
+		t.Errorf("got %d messages, want none", len(messages))
+	}
+}
+
+func TestSMTPMailerReportsUnreachableServer(t *testing.T) {
+	server := smtptest.NewServer()
+	addr := server.Addr
+	server.Close()
+
+	err := NewSMTPMailer(addr, "chores@example.com", "", "").Send("parent@example.com", "Hi", "text/plain", "Hello")
+	if err == nil {
+		t.Fatal("expected an error sending to a closed server")
+	}
+}
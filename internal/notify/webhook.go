@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"Adven-Chores/internal/models"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookNotifier posts notifications as JSON to the parent's webhook URL
+type WebhookNotifier struct {
+	Client *http.Client
+}
+
+func NewWebhookNotifier() *WebhookNotifier {
+	return &WebhookNotifier{Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (wn *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+func (wn *WebhookNotifier) Notify(prefs *models.NotificationPreferences, n *models.Notification) error {
+	if !prefs.WebhookEnabled || prefs.WebhookURL == "" {
+		return nil
+	}
+
+	payload, err := json.Marshal(struct {
+		Kind    string `json:"kind"`
+		ChildID int64  `json:"child_id,omitempty"`
+		Title   string `json:"title"`
+		Body    string `json:"body"`
+	}{
+		Kind:    n.Kind,
+		ChildID: n.ChildID,
+		Title:   n.Title,
+		Body:    n.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %v", err)
+	}
+
+	resp, err := wn.Client.Post(prefs.WebhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"Adven-Chores/internal/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookNotifierPostsJSON(t *testing.T) {
+	var got struct {
+		Kind    string `json:"kind"`
+		ChildID int64  `json:"child_id"`
+		Title   string `json:"title"`
+		Body    string `json:"body"`
+	}
+	var contentType string
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	prefs := &models.NotificationPreferences{WebhookEnabled: true, WebhookURL: server.URL}
+	n := &models.Notification{Kind: "assignment.completed", ChildID: 3, Title: "Chore completed", Body: "Sam completed \"Dishes\""}
+
+	err := NewWebhookNotifier().Notify(prefs, n)
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if requests != 1 {
+		t.Fatalf("got %d requests, want 1", requests)
+	}
+	if contentType != "application/json" {
+		t.Errorf("content type = %q, want application/json", contentType)
+	}
+	if got.Kind != n.Kind || got.ChildID != n.ChildID || got.Title != n.Title || got.Body != n.Body {
+		t.Errorf("payload = %+v, want %+v", got, n)
+	}
+}
+
+func TestWebhookNotifierReportsErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	prefs := &models.NotificationPreferences{WebhookEnabled: true, WebhookURL: server.URL}
+
+	err := NewWebhookNotifier().Notify(prefs, &models.Notification{Title: "Chore completed"})
+	if err == nil {
+		t.Fatal("expected an error for a 500 response")
+	}
+}
+
+func TestWebhookNotifierSkipsWhenOff(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	prefs := &models.NotificationPreferences{WebhookEnabled: false, WebhookURL: server.URL}
+
+	err := NewWebhookNotifier().Notify(prefs, &models.Notification{Title: "Chore completed"})
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("got %d requests, want none", requests)
+	}
+}
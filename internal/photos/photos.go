@@ -0,0 +1,259 @@
+package photos
+
+import (
+	"Adven-Chores/internal/models"
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// MaxUploadSize is the largest photo a child may upload
+	MaxUploadSize = 8 << 20
+	// MaxPixels guards against images that are small on disk but huge once decoded
+	MaxPixels = 40_000_000
+	// ThumbSize is the longest edge of a generated thumbnail
+	ThumbSize = 240
+)
+
+var (
+	ErrTooLarge    = errors.New("photo is larger than 8 MB")
+	ErrUnsupported = errors.New("photo must be a JPEG, PNG or GIF image")
+)
+
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// Store keeps assignment photos and their thumbnails on local disk
+type Store struct {
+	DB  *sql.DB
+	Dir string
+	// Retention is how long a photo is kept when the assignment is never rewarded
+	Retention time.Duration
+}
+
+// NewStore creates the photo directory if needed
+func NewStore(db *sql.DB, dir string, retention time.Duration) (*Store, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create photo directory: %v", err)
+	}
+	return &Store{DB: db, Dir: dir, Retention: retention}, nil
+}
+
+// Save validates an uploaded image, writes it and a thumbnail to disk and
+// records it against the assignment
+func (s *Store) Save(userID int, assignmentID int64, r io.Reader) (*models.AssignmentPhoto, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxUploadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read photo: %v", err)
+	}
+	if len(data) > MaxUploadSize {
+		return nil, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := extensions[contentType]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+
+	base := fmt.Sprintf("%d-%d", assignmentID, time.Now().UnixNano())
+	photo := &models.AssignmentPhoto{
+		AssignmentID:  assignmentID,
+		UserID:        userID,
+		Filename:      base + ext,
+		ThumbFilename: base + "-thumb.jpg",
+		ContentType:   contentType,
+	}
+
+	err = os.WriteFile(s.path(photo.Filename), data, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write photo: %v", err)
+	}
+
+	var thumb bytes.Buffer
+	err = jpeg.Encode(&thumb, Thumbnail(img, ThumbSize), &jpeg.Options{Quality: 80})
+	if err == nil {
+		err = os.WriteFile(s.path(photo.ThumbFilename), thumb.Bytes(), 0o644)
+	}
+	if err != nil {
+		os.Remove(s.path(photo.Filename))
+		return nil, fmt.Errorf("failed to write thumbnail: %v", err)
+	}
+
+	// Replace any earlier photo for this assignment
+	previous, _ := models.GetAssignmentPhoto(s.DB, assignmentID)
+
+	err = photo.Save(s.DB)
+	if err != nil {
+		s.removeFiles(photo)
+		return nil, err
+	}
+	if previous != nil {
+		s.removeFiles(previous)
+	}
+
+	return photo, nil
+}
+
+// Open returns the path and content type of an assignment's photo or thumbnail
+func (s *Store) Open(photo *models.AssignmentPhoto, thumb bool) (string, string) {
+	if thumb {
+		return s.path(photo.ThumbFilename), "image/jpeg"
+	}
+	return s.path(photo.Filename), photo.ContentType
+}
+
+// Remove deletes the photo attached to an assignment, if any
+func (s *Store) Remove(assignmentID int64) error {
+	photo, err := models.GetAssignmentPhoto(s.DB, assignmentID)
+	if err != nil {
+		return nil
+	}
+	return s.delete(photo)
+}
+
+// Cleanup deletes photos past the retention period, photos whose assignment
+// has been approved and rewarded, and photos whose assignment was removed
+func (s *Store) Cleanup() error {
+	expired, err := models.GetOrphanedAssignmentPhotos(s.DB)
+	if err != nil {
+		return err
+	}
+
+	if s.Retention > 0 {
+		old, err := models.GetAssignmentPhotosBefore(s.DB, time.Now().Add(-s.Retention))
+		if err != nil {
+			return err
+		}
+		expired = append(expired, old...)
+	}
+
+	for _, photo := range expired {
+		err := s.delete(photo)
+		if err != nil {
+			log.Printf("photo cleanup: %v", err)
+		}
+	}
+	return nil
+}
+
+// Run calls Cleanup on the given interval until the process exits
+func (s *Store) Run(interval time.Duration) {
+	for {
+		err := s.Cleanup()
+		if err != nil {
+			log.Printf("photo cleanup: %v", err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+func (s *Store) delete(photo *models.AssignmentPhoto) error {
+	err := models.DeleteAssignmentPhoto(s.DB, photo.ID)
+	if err != nil {
+		return err
+	}
+	s.removeFiles(photo)
+	return nil
+}
+
+func (s *Store) removeFiles(photo *models.AssignmentPhoto) {
+	for _, name := range []string{photo.Filename, photo.ThumbFilename} {
+		err := os.Remove(s.path(name))
+		if err != nil && !os.IsNotExist(err) {
+			log.Printf("failed to remove photo file %s: %v", name, err)
+		}
+	}
+}
+
+// path keeps stored file names inside the photo directory
+func (s *Store) path(name string) string {
+	return filepath.Join(s.Dir, filepath.Base(name))
+}
+
+// Thumbnail scales an image so its longest edge is at most size pixels,
+// averaging a grid of source samples for each output pixel
+func Thumbnail(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	tw, th := w, h
+	if w > size || h > size {
+		if w >= h {
+			tw, th = size, h*size/w
+		} else {
+			tw, th = w*size/h, size
+		}
+	}
+	if tw < 1 {
+		tw = 1
+	}
+	if th < 1 {
+		th = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := span(b.Min.Y, h, th, y)
+		for x := 0; x < tw; x++ {
+			x0, x1 := span(b.Min.X, w, tw, x)
+
+			// Sample at most a 4x4 grid per output pixel to keep large photos fast
+			stepX, stepY := max((x1-x0)/4, 1), max((y1-y0)/4, 1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy += stepY {
+				for sx := x0; sx < x1; sx += stepX {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(bl / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
+
+// span returns the source range covered by output index i
+func span(origin, src, dst, i int) (int, int) {
+	start := origin + i*src/dst
+	end := origin + (i+1)*src/dst
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
@@ -0,0 +1,138 @@
+package printable
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PDF is a minimal PDF writer for the printables: text in the standard
+// Helvetica fonts, lines and boxes. Positions are in points from the top
+// left corner of the page.
+type PDF struct {
+	Width  float64
+	Height float64
+	pages  []*bytes.Buffer
+	page   *bytes.Buffer
+}
+
+// function to create an empty PDF with pages of the given paper size
+func NewPDF(paper *Paper) *PDF {
+	return &PDF{Width: paper.Width, Height: paper.Height}
+}
+
+// function to start a new page; drawing goes to the newest page
+func (p *PDF) AddPage() {
+	p.page = &bytes.Buffer{}
+	p.pages = append(p.pages, p.page)
+}
+
+// function to write a line of text with its baseline at y
+func (p *PDF) Text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(p.page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, p.Height-y, escapeText(s))
+}
+
+// function to write text centred on x
+func (p *PDF) CenteredText(x, y, size float64, bold bool, s string) {
+	p.Text(x-TextWidth(s, size)/2, y, size, bold, s)
+}
+
+// function to draw a line
+func (p *PDF) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(p.page, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, p.Height-y1, x2, p.Height-y2)
+}
+
+// function to draw the outline of a box; dashed boxes are for cutting out
+func (p *PDF) Rect(x, y, w, h, width float64, dashed bool) {
+	if dashed {
+		p.page.WriteString("[4 3] 0 d\n")
+	}
+	fmt.Fprintf(p.page, "%.2f w %.2f %.2f %.2f %.2f re S\n", width, x, p.Height-y-h, w, h)
+	if dashed {
+		p.page.WriteString("[] 0 d\n")
+	}
+}
+
+// function to fill a box with a shade of grey between 0 (black) and 1 (white)
+func (p *PDF) Fill(x, y, w, h, grey float64) {
+	fmt.Fprintf(p.page, "%.2f g %.2f %.2f %.2f %.2f re f 0 g\n", grey, x, p.Height-y-h, w, h)
+}
+
+// function to write out the finished document
+func (p *PDF) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1 to 4 are the catalogue, the page tree and the fonts; each
+	// page then takes two objects, the page and its content stream
+	var kids []string
+	for i := range p.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5+2*i))
+	}
+
+	out.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range p.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			p.Width, p.Height, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.WriteTo(w)
+}
+
+// function to escape text for a PDF string. The standard fonts only cover
+// Latin-1, so other characters such as emoji icons are left out.
+func escapeText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '’':
+			b.WriteByte('\'')
+		case r >= 32 && r < 127:
+			b.WriteRune(r)
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&b, "\\%03o", r)
+		}
+	}
+	return b.String()
+}
+
+// function to estimate the width of text in Helvetica; good enough to
+// centre and shorten text
+func TextWidth(s string, size float64) float64 {
+	return float64(len([]rune(s))) * size * 0.52
+}
+
+// function to shorten text so it fits in a width
+func Fit(s string, size, width float64) string {
+	runes := []rune(s)
+	for len(runes) > 1 && TextWidth(string(runes), size) > width {
+		runes = runes[:len(runes)-2]
+		runes = append(runes, '.')
+	}
+	return string(runes)
+}
@@ -0,0 +1,161 @@
+package printable
+
+import (
+	"Adven-Chores/internal/models"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// function to get the byte offset of every object from a PDF's xref table
+func xrefOffsets(t *testing.T, doc []byte) []int {
+	t.Helper()
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(doc)
+	if m == nil {
+		t.Fatalf("no startxref at the end of the document")
+	}
+	start, _ := strconv.Atoi(string(m[1]))
+
+	lines := strings.Split(string(doc[start:]), "\n")
+	if lines[0] != "xref" {
+		t.Fatalf("startxref points at %q, not the xref table", lines[0])
+	}
+	var first, count int
+	if _, err := fmt.Sscanf(lines[1], "%d %d", &first, &count); err != nil || first != 0 {
+		t.Fatalf("bad xref subsection header %q", lines[1])
+	}
+
+	// Entry 0 is the head of the free list; the rest are in-use objects
+	var offsets []int
+	for i, line := range lines[3 : 2+count] {
+		var offset, generation int
+		var use string
+		if _, err := fmt.Sscanf(line, "%d %d %s", &offset, &generation, &use); err != nil || use != "n" {
+			t.Fatalf("bad xref entry %d: %q", i+1, line)
+		}
+		if len(line) != 19 {
+			t.Errorf("xref entry %d is %d bytes, want 20 with the newline", i+1, len(line)+1)
+		}
+		offsets = append(offsets, offset)
+	}
+	return offsets
+}
+
+func TestWriteChartPDFOffsets(t *testing.T) {
+	// Enough chores to run onto a third page
+	var assignments []*models.Assignment
+	for i := 0; i < 60; i++ {
+		chore := &models.Chore{Description: fmt.Sprintf("Chore (%d) for Zoë", i+1), IsRequired: i%2 == 0}
+		assignments = append(assignments, &models.Assignment{ID: int64(i + 1), Chore: chore})
+	}
+	week := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+	chart := BuildChart(&models.Child{Name: "Sam"}, assignments, week, week)
+
+	var out bytes.Buffer
+	if err := WriteChartPDF(&out, chart, GetPaper("a4")); err != nil {
+		t.Fatalf("WriteChartPDF: %v", err)
+	}
+	doc := out.Bytes()
+
+	if !bytes.HasPrefix(doc, []byte("%PDF-1.4\n")) {
+		t.Errorf("document doesn't start with a PDF header")
+	}
+
+	offsets := xrefOffsets(t, doc)
+	for i, offset := range offsets {
+		want := fmt.Sprintf("%d 0 obj\n", i+1)
+		if offset >= len(doc) || !bytes.HasPrefix(doc[offset:], []byte(want)) {
+			t.Errorf("xref offset %d for object %d doesn't point at %q", offset, i+1, want)
+		}
+	}
+
+	pages := (len(offsets) - 4) / 2
+	if pages != 3 || len(offsets) != 4+2*pages {
+		t.Fatalf("got %d objects, want 4 plus 2 for each of 3 pages", len(offsets))
+	}
+
+	// The page tree lists every page, and each page points at the content
+	// stream right after it
+	var kids []string
+	for i := 0; i < pages; i++ {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5+2*i))
+	}
+	if want := fmt.Sprintf("/Kids [%s] /Count %d", strings.Join(kids, " "), pages); !bytes.Contains(doc, []byte(want)) {
+		t.Errorf("page tree doesn't contain %q", want)
+	}
+	length := regexp.MustCompile(`^<< /Length (\d+) >>\nstream\n`)
+	for i := 0; i < pages; i++ {
+		page := string(doc[offsets[4+2*i]:offsets[5+2*i]])
+		if !strings.Contains(page, "/Type /Page ") || !strings.Contains(page, fmt.Sprintf("/Contents %d 0 R", 6+2*i)) {
+			t.Errorf("page %d object = %q", i+1, page)
+		}
+
+		end := len(doc)
+		if 6+2*i < len(offsets) {
+			end = offsets[6+2*i]
+		}
+		stream := strings.TrimPrefix(string(doc[offsets[5+2*i]:end]), fmt.Sprintf("%d 0 obj\n", 6+2*i))
+		m := length.FindStringSubmatch(stream)
+		if m == nil {
+			t.Fatalf("page %d contents = %.40q", i+1, stream)
+		}
+		n, _ := strconv.Atoi(m[1])
+		if rest := stream[len(m[0]):]; len(rest) < n || !strings.HasPrefix(rest[n:], "endstream\nendobj\n") {
+			t.Errorf("page %d stream /Length %d doesn't end at endstream", i+1, n)
+		}
+	}
+
+	if !bytes.Contains(doc, []byte(`(Chore \(60\) for Zo\353 \(any day\)) Tj`)) {
+		t.Errorf("the last chore isn't on the chart")
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+	}{
+		{"Dishes", "Dishes"},
+		{"Feed (the) cat", `Feed \(the\) cat`},
+		{`C:\chores`, `C:\\chores`},
+		{"Café crème", `Caf\351 cr\350me`},
+		{"5 × 2 ½", `5 \327 2 \275`},
+		{"Sam’s room", "Sam's room"},
+		{"⭐ Star chart 🎉", " Star chart "},
+		{"Tidy\tup\n", "Tidyup"},
+		{"", ""},
+	} {
+		if got := escapeText(tt.in); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	for _, tt := range []struct {
+		in    string
+		size  float64
+		width float64
+		want  string
+	}{
+		{"Dishes", 10, 100, "Dishes"},
+		{"Hello world", 10, 57.2, "Hello world"},
+		{"Hello world", 10, 57, "Hello wor."},
+		{"Hello world", 10, 30, "Hell."},
+		{"Zoë’s bedroom", 10, 42, "Zoë’s b."},
+		{"Hello", 10, 1, "."},
+		{"", 10, 1, ""},
+	} {
+		got := Fit(tt.in, tt.size, tt.width)
+		if got != tt.want {
+			t.Errorf("Fit(%q, %v, %v) = %q, want %q", tt.in, tt.size, tt.width, got, tt.want)
+		}
+		if got != tt.in && TextWidth(got, tt.size) > tt.width && len([]rune(got)) > 1 {
+			t.Errorf("Fit(%q, %v, %v) = %q is still too wide", tt.in, tt.size, tt.width, got)
+		}
+	}
+}
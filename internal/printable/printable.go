@@ -0,0 +1,179 @@
+// Package printable lays out chore charts and reward coupons for printing,
+// for children who don't use a tablet. The same layout data renders as a
+// print-optimized HTML page and as a PDF.
+package printable
+
+import (
+	"Adven-Chores/internal/calendar"
+	"Adven-Chores/internal/models"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Paper is a page size, in points
+type Paper struct {
+	Key    string
+	Name   string
+	Width  float64
+	Height float64
+}
+
+// paper sizes that can be printed on; A4 is the default
+var Papers = []*Paper{
+	{Key: "a4", Name: "A4", Width: 595, Height: 842},
+	{Key: "letter", Name: "Letter", Width: 612, Height: 792},
+}
+
+// function to get a paper size by key, falling back to A4
+func GetPaper(key string) *Paper {
+	for _, p := range Papers {
+		if p.Key == key {
+			return p
+		}
+	}
+	return Papers[0]
+}
+
+// ChartRow is one chore on a chore chart. Days marks the days of the week it
+// is due; chores without a due date get a single box for any day.
+type ChartRow struct {
+	Assignment *models.Assignment
+	Days       []bool
+	Anytime    bool
+}
+
+// Chart is a child's chore chart for one week
+type Chart struct {
+	Child *models.Child
+	Week  *calendar.Week
+	Rows  []*ChartRow
+}
+
+// function to lay a child's assignments out on a chart for the week
+// containing start, using the same week as the calendar
+func BuildChart(child *models.Child, assignments []*models.Assignment, start, now time.Time) *Chart {
+	chart := &Chart{Child: child, Week: calendar.BuildWeek(assignments, start, now)}
+
+	for _, a := range assignments {
+		row := &ChartRow{Assignment: a, Anytime: a.DueDate == ""}
+		due := false
+		for _, day := range chart.Week.Days {
+			on := a.OccursOn(day.Date)
+			row.Days = append(row.Days, on)
+			due = due || on
+		}
+		if row.Anytime || due {
+			chart.Rows = append(chart.Rows, row)
+		}
+	}
+
+	return chart
+}
+
+// Coupon is a redeemed reward to print and hand over
+type Coupon struct {
+	Child      *models.Child
+	Redemption *models.RewardRedemption
+}
+
+// sizes of the PDF layouts, in points
+const (
+	margin        = 36
+	rowHeight     = 28
+	boxSize       = 14
+	couponsAcross = 2
+	couponsDown   = 4
+	couponGap     = 12
+)
+
+// function to draw a chore chart as a PDF, continuing on more pages when
+// there are too many chores for one
+func WriteChartPDF(w io.Writer, chart *Chart, paper *Paper) error {
+	pdf := NewPDF(paper)
+	choreWidth := (paper.Width - 2*margin) * 0.4
+	dayWidth := (paper.Width - 2*margin - choreWidth) / 7
+
+	var y float64
+	header := func() {
+		pdf.AddPage()
+		pdf.Text(margin, margin+18, 20, true, chart.Child.Name+"'s Chore Chart")
+		pdf.Text(margin, margin+38, 11, false, "Week of "+chart.Week.Start.Format("Monday, January 2, 2006"))
+
+		y = margin + 56
+		pdf.Fill(margin, y, paper.Width-2*margin, rowHeight, 0.9)
+		pdf.Text(margin+6, y+18, 11, true, "Chore")
+		for i, day := range chart.Week.Days {
+			pdf.CenteredText(margin+choreWidth+dayWidth*(float64(i)+0.5), y+18, 11, true, day.Date.Format("Mon 2"))
+		}
+		y += rowHeight
+	}
+	header()
+
+	for _, row := range chart.Rows {
+		if y+rowHeight > paper.Height-margin {
+			header()
+		}
+
+		label := row.Assignment.Chore.Description
+		if row.Assignment.Chore.IsRequired {
+			label += " *"
+		}
+		if row.Anytime {
+			label += " (any day)"
+		}
+		pdf.Text(margin+6, y+18, 11, false, Fit(label, 11, choreWidth-12))
+
+		for i, due := range row.Days {
+			if due || (row.Anytime && i == 0) {
+				x := margin + choreWidth + dayWidth*(float64(i)+0.5) - boxSize/2
+				pdf.Rect(x, y+(rowHeight-boxSize)/2, boxSize, boxSize, 1, false)
+			}
+		}
+		pdf.Line(margin, y+rowHeight, paper.Width-margin, y+rowHeight, 0.5)
+		y += rowHeight
+	}
+
+	if len(chart.Rows) == 0 {
+		pdf.Text(margin+6, y+18, 11, false, "No chores this week.")
+	}
+	pdf.Text(margin, paper.Height-margin, 9, false, "* required chore")
+
+	_, err := pdf.WriteTo(w)
+	return err
+}
+
+// function to draw coupons as a PDF, several to a page with dashed lines
+// to cut along
+func WriteCouponsPDF(w io.Writer, coupons []*Coupon, paper *Paper) error {
+	pdf := NewPDF(paper)
+	width := (paper.Width - 2*margin - couponGap*(couponsAcross-1)) / couponsAcross
+	height := (paper.Height - 2*margin - couponGap*(couponsDown-1)) / couponsDown
+
+	if len(coupons) == 0 {
+		pdf.AddPage()
+		pdf.Text(margin, margin+18, 11, false, "No rewards redeemed yet.")
+	}
+
+	for i, c := range coupons {
+		slot := i % (couponsAcross * couponsDown)
+		if slot == 0 {
+			pdf.AddPage()
+		}
+		x := margin + float64(slot%couponsAcross)*(width+couponGap)
+		y := margin + float64(slot/couponsAcross)*(height+couponGap)
+		centre := x + width/2
+
+		pdf.Rect(x, y, width, height, 1, true)
+		pdf.CenteredText(centre, y+30, 12, true, "REWARD COUPON")
+		pdf.CenteredText(centre, y+height/2, 18, true, Fit(c.Redemption.Description, 18, width-24))
+		pdf.CenteredText(centre, y+height/2+24, 11, false, "for "+c.Child.Name)
+		pdf.CenteredText(centre, y+height-44, 9, false,
+			fmt.Sprintf("Redeemed %s for %d points", c.Redemption.RedeemedAt.Local().Format("Jan 2, 2006"), c.Redemption.Points))
+		pdf.Text(x+16, y+height-18, 9, false, "Signed: "+strings.Repeat("_", 24))
+	}
+
+	_, err := pdf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,169 @@
+package webhooks
+
+import (
+	"Adven-Chores/internal/events"
+	"Adven-Chores/internal/models"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Event types a webhook can subscribe to
+var EventTypes = []string{
+	events.AssignmentCompleted,
+	events.AssignmentRewarded,
+	events.RewardRedeemed,
+	events.ChildLevelUp,
+	events.PointsAdjusted,
+	events.CompetitionClosed,
+	events.QuestCompleted,
+	events.BountyClaimed,
+	events.BountyExpired,
+	events.SwapCompleted,
+}
+
+// Header names sent with every delivery
+const (
+	SignatureHeader = "X-AdvenChores-Signature"
+	EventHeader     = "X-AdvenChores-Event"
+)
+
+// Deliverer posts household events to the matching webhook subscriptions,
+// retrying failed deliveries with exponential backoff
+type Deliverer struct {
+	DB          *sql.DB
+	Client      *http.Client
+	MaxAttempts int
+	BaseDelay   time.Duration
+}
+
+func NewDeliverer(db *sql.DB) *Deliverer {
+	return &Deliverer{
+		DB:          db,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts: 5,
+		BaseDelay:   2 * time.Second,
+	}
+}
+
+// Payload is the JSON body posted to webhook URLs
+type Payload struct {
+	Event       string       `json:"event"`
+	HouseholdID int          `json:"household_id"`
+	Timestamp   time.Time    `json:"timestamp"`
+	Data        events.Event `json:"data"`
+}
+
+// function to deliver an event to every webhook of its household that
+// subscribes to the event type; suitable as an events.Hub listener
+func (d *Deliverer) HandleEvent(e events.Event) {
+	go func() {
+		webhooks, err := models.GetWebhooksByUserID(d.DB, e.UserID)
+		if err != nil {
+			log.Printf("Webhooks: failed to load webhooks for user %d: %v", e.UserID, err)
+			return
+		}
+
+		for _, webhook := range webhooks {
+			if webhook.Wants(e.Type) {
+				go d.Deliver(webhook, e)
+			}
+		}
+	}()
+}
+
+// function to deliver one event to one webhook, logging every attempt
+func (d *Deliverer) Deliver(webhook *models.Webhook, e events.Event) {
+	body, err := json.Marshal(Payload{
+		Event:       e.Type,
+		HouseholdID: e.UserID,
+		Timestamp:   e.Time,
+		Data:        e,
+	})
+	if err != nil {
+		log.Printf("Webhooks: failed to encode payload: %v", err)
+		return
+	}
+
+	delay := d.BaseDelay
+	for attempt := 1; attempt <= d.MaxAttempts; attempt++ {
+		delivery := &models.WebhookDelivery{
+			WebhookID: webhook.ID,
+			UserID:    webhook.UserID,
+			EventType: e.Type,
+			Payload:   string(body),
+			Attempt:   attempt,
+		}
+
+		delivery.StatusCode, err = d.post(webhook, e.Type, body)
+		if err != nil {
+			delivery.Error = err.Error()
+		} else {
+			delivery.Success = true
+		}
+
+		if err := delivery.Save(d.DB); err != nil {
+			log.Printf("Webhooks: failed to record delivery: %v", err)
+		}
+
+		if delivery.Success {
+			return
+		}
+
+		if attempt < d.MaxAttempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+
+	log.Printf("Webhooks: giving up on %s for webhook %d after %d attempts", e.Type, webhook.ID, d.MaxAttempts)
+}
+
+// function to post a signed payload and return the response status code
+func (d *Deliverer) post(webhook *models.Webhook, eventType string, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventType)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(webhook.Secret, body))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// function to compute the hex HMAC-SHA256 signature of a payload
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// function to generate a random signing secret for a new webhook
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,239 @@
+package webhooks
+
+import (
+	"Adven-Chores/internal/database"
+	"Adven-Chores/internal/events"
+	"Adven-Chores/internal/models"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// function to open a fresh database in a temporary home directory
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	db, err := database.InitDB()
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// function to add a parent with a webhook pointing at the given URL
+func addWebhook(t *testing.T, db *sql.DB, url string) *models.Webhook {
+	t.Helper()
+
+	result, err := db.Exec("INSERT INTO users (username, email, password_hash) VALUES ('alex', 'alex@example.com', '')")
+	if err != nil {
+		t.Fatalf("failed to add user: %v", err)
+	}
+	userID, _ := result.LastInsertId()
+
+	webhook := &models.Webhook{UserID: int(userID), URL: url, Secret: "s3cret", EventTypes: []string{events.AssignmentRewarded}}
+	if err := webhook.Save(db); err != nil {
+		t.Fatalf("failed to add webhook: %v", err)
+	}
+	return webhook
+}
+
+// function to get a webhook's delivery log, oldest attempt first
+func deliveries(t *testing.T, db *sql.DB, userID int) []*models.WebhookDelivery {
+	t.Helper()
+
+	logged, err := models.GetWebhookDeliveriesByUserID(db, userID, 100)
+	if err != nil {
+		t.Fatalf("GetWebhookDeliveriesByUserID: %v", err)
+	}
+	for i, j := 0, len(logged)-1; i < j; i, j = i+1, j-1 {
+		logged[i], logged[j] = logged[j], logged[i]
+	}
+	return logged
+}
+
+// server that answers with the given status codes in turn, repeating the
+// last one, and remembers when each request arrived
+type recorder struct {
+	mu       sync.Mutex
+	statuses []int
+	times    []time.Time
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	status := rec.statuses[min(len(rec.times), len(rec.statuses)-1)]
+	rec.times = append(rec.times, time.Now())
+	rec.requests = append(rec.requests, r)
+	rec.bodies = append(rec.bodies, body)
+	w.WriteHeader(status)
+}
+
+func testEvent(userID int) events.Event {
+	return events.Event{
+		Type:        events.AssignmentRewarded,
+		UserID:      userID,
+		ChildID:     3,
+		ChildName:   "Sam",
+		Description: "Dishes",
+		Points:      10,
+		Time:        time.Date(2026, 10, 19, 17, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestDeliverSignsPayload(t *testing.T) {
+	db := testDB(t)
+	rec := &recorder{statuses: []int{http.StatusNoContent}}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+	webhook := addWebhook(t, db, server.URL)
+
+	NewDeliverer(db).Deliver(webhook, testEvent(webhook.UserID))
+
+	if len(rec.requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(rec.requests))
+	}
+	r, body := rec.requests[0], rec.bodies[0]
+
+	if signature := r.Header.Get(SignatureHeader); signature != "sha256="+Sign("s3cret", body) {
+		t.Errorf("signature = %q doesn't match the body", signature)
+	}
+	if event := r.Header.Get(EventHeader); event != events.AssignmentRewarded {
+		t.Errorf("event header = %q, want %q", event, events.AssignmentRewarded)
+	}
+	if contentType := r.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("content type = %q, want application/json", contentType)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if payload.Event != events.AssignmentRewarded || payload.HouseholdID != webhook.UserID {
+		t.Errorf("payload = %+v", payload)
+	}
+	if payload.Data.ChildID != 3 || payload.Data.Points != 10 || payload.Data.Description != "Dishes" {
+		t.Errorf("payload data = %+v", payload.Data)
+	}
+
+	logged := deliveries(t, db, webhook.UserID)
+	if len(logged) != 1 {
+		t.Fatalf("got %d logged deliveries, want 1", len(logged))
+	}
+	d := logged[0]
+	if !d.Success || d.StatusCode != http.StatusNoContent || d.Attempt != 1 || d.Error != "" {
+		t.Errorf("delivery = %+v", d)
+	}
+	if d.Payload != string(body) {
+		t.Errorf("logged payload = %q, want the body that was sent", d.Payload)
+	}
+}
+
+func TestDeliverRetriesWithBackoff(t *testing.T) {
+	db := testDB(t)
+	rec := &recorder{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK}}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+	webhook := addWebhook(t, db, server.URL)
+
+	d := NewDeliverer(db)
+	d.BaseDelay = 20 * time.Millisecond
+	d.Deliver(webhook, testEvent(webhook.UserID))
+
+	if len(rec.times) != 3 {
+		t.Fatalf("got %d requests, want 3", len(rec.times))
+	}
+	// The wait doubles after each failure
+	if wait := rec.times[1].Sub(rec.times[0]); wait < d.BaseDelay {
+		t.Errorf("first retry after %v, want at least %v", wait, d.BaseDelay)
+	}
+	if wait := rec.times[2].Sub(rec.times[1]); wait < 2*d.BaseDelay {
+		t.Errorf("second retry after %v, want at least %v", wait, 2*d.BaseDelay)
+	}
+	// Every attempt carries the same signed body
+	for i, body := range rec.bodies {
+		if signature := rec.requests[i].Header.Get(SignatureHeader); signature != "sha256="+Sign("s3cret", body) {
+			t.Errorf("attempt %d: signature = %q doesn't match the body", i+1, signature)
+		}
+	}
+
+	logged := deliveries(t, db, webhook.UserID)
+	if len(logged) != 3 {
+		t.Fatalf("got %d logged deliveries, want 3", len(logged))
+	}
+	for i, want := range []struct {
+		status  int
+		success bool
+		err     string
+	}{
+		{http.StatusInternalServerError, false, "unexpected status 500"},
+		{http.StatusBadGateway, false, "unexpected status 502"},
+		{http.StatusOK, true, ""},
+	} {
+		got := logged[i]
+		if got.Attempt != i+1 || got.StatusCode != want.status || got.Success != want.success || got.Error != want.err {
+			t.Errorf("delivery %d = %+v, want status %d, success %v, error %q", i+1, got, want.status, want.success, want.err)
+		}
+		if got.EventType != events.AssignmentRewarded || got.URL != server.URL {
+			t.Errorf("delivery %d logged event %q to %q", i+1, got.EventType, got.URL)
+		}
+	}
+}
+
+func TestDeliverGivesUpAfterMaxAttempts(t *testing.T) {
+	db := testDB(t)
+	rec := &recorder{statuses: []int{http.StatusServiceUnavailable}}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+	webhook := addWebhook(t, db, server.URL)
+
+	d := NewDeliverer(db)
+	d.MaxAttempts = 3
+	d.BaseDelay = time.Millisecond
+	d.Deliver(webhook, testEvent(webhook.UserID))
+
+	if len(rec.times) != 3 {
+		t.Fatalf("got %d requests, want 3", len(rec.times))
+	}
+
+	logged := deliveries(t, db, webhook.UserID)
+	if len(logged) != 3 {
+		t.Fatalf("got %d logged deliveries, want 3", len(logged))
+	}
+	for i, got := range logged {
+		if got.Attempt != i+1 || got.Success || got.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("delivery %d = %+v", i+1, got)
+		}
+	}
+}
+
+func TestDeliverLogsUnreachableURL(t *testing.T) {
+	db := testDB(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+	webhook := addWebhook(t, db, url)
+
+	d := NewDeliverer(db)
+	d.MaxAttempts = 1
+	d.Deliver(webhook, testEvent(webhook.UserID))
+
+	logged := deliveries(t, db, webhook.UserID)
+	if len(logged) != 1 {
+		t.Fatalf("got %d logged deliveries, want 1", len(logged))
+	}
+	if logged[0].Success || logged[0].StatusCode != 0 || logged[0].Error == "" {
+		t.Errorf("delivery = %+v, want a failure with no status code", logged[0])
+	}
+}